@@ -0,0 +1,284 @@
+// Copyright 2025 Laszlo Consulting Kft.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/pflag"
+
+	"github.com/gimlet-io/capacitor/pkg/config"
+	"github.com/gimlet-io/capacitor/pkg/kubernetes"
+	"github.com/gimlet-io/capacitor/pkg/server"
+)
+
+// Exit codes of the diff subcommand, mirroring diff(1): 0 when nothing would change,
+// 1 when Flux would create, change or prune objects, and 2 on errors.
+const (
+	diffExitNoChanges = 0
+	diffExitChanges   = 1
+	diffExitError     = 2
+)
+
+// runDiff implements `next diff`: it renders the Flux diff of a Kustomization
+// against the live cluster without starting the web server.
+func runDiff(args []string) int {
+	cfg := config.New()
+	// Diffing doesn't change the cluster, there is nothing to audit
+	cfg.AuditLogPath = ""
+
+	var (
+		contextName   string
+		kustomization string
+		output        string
+		localSource   string
+		timeout       time.Duration
+	)
+
+	fs := pflag.NewFlagSet("diff", pflag.ContinueOnError)
+	fs.StringVar(&contextName, "context", "", "Kubeconfig context to use (defaults to the current context)")
+	fs.StringVar(&kustomization, "kustomization", "", "Kustomization to diff in namespace/name form")
+	fs.StringVarP(&output, "output", "o", "text", "Output format: text (unified diff) or json")
+	fs.StringVar(&localSource, "local-source", "", "Build the Kustomization from this local checkout of its source instead of the artifact in the cluster, the root of the repository (--local-source alone uses the current directory, --local-source=<dir> another one)")
+	fs.Lookup("local-source").NoOptDefVal = "."
+	fs.DurationVar(&timeout, "timeout", 5*time.Minute, "Timeout for building and diffing the Kustomization")
+	fs.StringVar(&cfg.KubeConfigPath, "kubeconfig", cfg.KubeConfigPath, "Path to kubeconfig file (KUBECONFIG)")
+	fs.BoolVar(&cfg.InsecureSkipTLSVerify, "insecure-skip-tls-verify", cfg.InsecureSkipTLSVerify, "Skip TLS certificate verification (insecure, use only for development) (KUBECONFIG_INSECURE_SKIP_TLS_VERIFY)")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: next diff --kustomization <namespace>/<name> [flags]\n\n%s", fs.FlagUsages())
+	}
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, pflag.ErrHelp) {
+			return diffExitNoChanges
+		}
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return diffExitError
+	}
+
+	// --local-source takes its directory as --local-source=<dir>, a separate word would be silently ignored
+	if fs.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "Error: unexpected argument %q, use --local-source=<dir> to diff against a directory\n", fs.Arg(0))
+		fs.Usage()
+		return diffExitError
+	}
+
+	// Environment variables override command line flags, same as for the server
+	if env := os.Getenv("KUBECONFIG"); env != "" {
+		cfg.KubeConfigPath = env
+	}
+	if env := os.Getenv("KUBECONFIG_INSECURE_SKIP_TLS_VERIFY"); env == "true" {
+		cfg.InsecureSkipTLSVerify = true
+	}
+
+	namespace, name, ok := strings.Cut(kustomization, "/")
+	if !ok || strings.TrimSpace(namespace) == "" || strings.TrimSpace(name) == "" {
+		fmt.Fprintln(os.Stderr, "Error: --kustomization is required in namespace/name form")
+		fs.Usage()
+		return diffExitError
+	}
+
+	if output != "text" && output != "json" {
+		fmt.Fprintf(os.Stderr, "Error: unsupported output format %q, use text or json\n", output)
+		return diffExitError
+	}
+
+	k8sClient, err := kubernetes.NewClient(cfg.KubeConfigPath, cfg.InsecureSkipTLSVerify, contextName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating Kubernetes client: %v\n", err)
+		return diffExitError
+	}
+
+	srv, err := server.New(cfg, k8sClient, version)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating server: %v\n", err)
+		return diffExitError
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var results []server.FluxDiffResult
+	if localSource != "" {
+		archive, archiveErr := archiveDirectory(localSource, server.MaxLocalSourceSize)
+		if archiveErr != nil {
+			fmt.Fprintf(os.Stderr, "Error archiving local source: %v\n", archiveErr)
+			return diffExitError
+		}
+		log.Printf("Diffing Kustomization %s/%s in context %s against the local source in %s", namespace, name, k8sClient.CurrentContext, localSource)
+		results, err = srv.DiffKustomizationWithLocalSource(ctx, k8sClient, namespace, name, archive)
+	} else {
+		log.Printf("Diffing Kustomization %s/%s in context %s", namespace, name, k8sClient.CurrentContext)
+		results, err = srv.DiffKustomization(ctx, k8sClient, namespace, name)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error generating diff: %v\n", err)
+		return diffExitError
+	}
+
+	switch output {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(results); err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding diff result: %v\n", err)
+			return diffExitError
+		}
+	default:
+		if err := writeUnifiedDiffs(os.Stdout, results); err != nil {
+			fmt.Fprintf(os.Stderr, "Error rendering diff: %v\n", err)
+			return diffExitError
+		}
+	}
+
+	if hasDiffChanges(results) {
+		return diffExitChanges
+	}
+	return diffExitNoChanges
+}
+
+// hasDiffChanges reports whether any object would be created, changed or pruned
+func hasDiffChanges(results []server.FluxDiffResult) bool {
+	for _, r := range results {
+		if r.HasChanges || r.Created || r.Deleted {
+			return true
+		}
+	}
+	return false
+}
+
+// writeUnifiedDiffs writes a unified diff for every created, changed or pruned object.
+// Unchanged objects are skipped.
+func writeUnifiedDiffs(w io.Writer, results []server.FluxDiffResult) error {
+	for _, r := range results {
+		if !r.HasChanges && !r.Created && !r.Deleted {
+			continue
+		}
+
+		fromFile := "cluster/" + r.FileName
+		toFile := "desired/" + r.FileName
+		if r.Created {
+			fromFile = "/dev/null"
+		}
+		if r.Deleted {
+			toFile = "/dev/null"
+		}
+
+		diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        diffLines(r.ClusterYaml),
+			B:        diffLines(r.AppliedYaml),
+			FromFile: fromFile,
+			ToFile:   toFile,
+			Context:  3,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to diff %s: %w", r.FileName, err)
+		}
+		if _, err := io.WriteString(w, diff); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// diffLines splits a document into lines for difflib. Unlike difflib.SplitLines it doesn't add an empty line
+// after the final newline, so created and pruned objects diff against no lines at all.
+func diffLines(doc string) []string {
+	if doc == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(doc, "\n")
+	if last := len(lines) - 1; lines[last] == "" {
+		lines = lines[:last]
+	} else {
+		lines[last] += "\n"
+	}
+	return lines
+}
+
+// archiveDirectory returns a tar.gz of the regular files and directories under dir, the way the diff
+// endpoint takes a local source. Version control directories are left out. It fails once the archive
+// grows beyond maxSize, like the endpoint refuses it.
+func archiveDirectory(dir string, maxSize int) ([]byte, error) {
+	buf := &limitedBuffer{max: maxSize}
+	gz := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gz)
+
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		if d.IsDir() && (d.Name() == ".git" || d.Name() == ".hg" || d.Name() == ".svn") {
+			return filepath.SkipDir
+		}
+		if !d.IsDir() && !d.Type().IsRegular() {
+			return nil // symlinks, sockets and the like are not part of a source artifact
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// limitedBuffer is a bytes.Buffer that refuses to grow beyond max bytes
+type limitedBuffer struct {
+	bytes.Buffer
+	max int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.Len()+len(p) > b.max {
+		return 0, fmt.Errorf("local source is larger than %d MiB compressed", b.max>>20)
+	}
+	return b.Buffer.Write(p)
+}
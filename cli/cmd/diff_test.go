@@ -0,0 +1,170 @@
+// Copyright 2025 Laszlo Consulting Kft.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gimlet-io/capacitor/pkg/server"
+)
+
+func TestHasDiffChanges(t *testing.T) {
+	unchanged := server.FluxDiffResult{FileName: "ConfigMap/apps/settings", ClusterYaml: "a: 1\n", AppliedYaml: "a: 1\n"}
+
+	tests := []struct {
+		name    string
+		results []server.FluxDiffResult
+		want    bool
+	}{
+		{name: "no objects"},
+		{name: "unchanged", results: []server.FluxDiffResult{unchanged}},
+		{name: "changed", results: []server.FluxDiffResult{unchanged, {FileName: "Deployment/apps/web", HasChanges: true}}, want: true},
+		{name: "created", results: []server.FluxDiffResult{unchanged, {FileName: "Service/apps/web", Created: true}}, want: true},
+		{name: "pruned", results: []server.FluxDiffResult{{FileName: "Service/apps/legacy", Deleted: true}, unchanged}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hasDiffChanges(tt.results); got != tt.want {
+				t.Errorf("hasDiffChanges() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteUnifiedDiffs(t *testing.T) {
+	tests := []struct {
+		name   string
+		result server.FluxDiffResult
+		want   string
+	}{
+		{
+			name:   "unchanged",
+			result: server.FluxDiffResult{FileName: "ConfigMap/apps/settings", ClusterYaml: "a: 1\n", AppliedYaml: "a: 1\n"},
+		},
+		{
+			name: "changed",
+			result: server.FluxDiffResult{
+				FileName:    "Deployment/apps/web",
+				ClusterYaml: "kind: Deployment\nreplicas: 1\n",
+				AppliedYaml: "kind: Deployment\nreplicas: 3\n",
+				HasChanges:  true,
+			},
+			want: "--- cluster/Deployment/apps/web\n+++ desired/Deployment/apps/web\n@@ -1,2 +1,2 @@\n kind: Deployment\n-replicas: 1\n+replicas: 3\n",
+		},
+		{
+			name:   "created",
+			result: server.FluxDiffResult{FileName: "Service/apps/web", AppliedYaml: "kind: Service\n", Created: true, HasChanges: true},
+			want:   "--- /dev/null\n+++ desired/Service/apps/web\n@@ -0,0 +1 @@\n+kind: Service\n",
+		},
+		{
+			name:   "pruned",
+			result: server.FluxDiffResult{FileName: "Service/apps/legacy", ClusterYaml: "kind: Service\n", Deleted: true},
+			want:   "--- cluster/Service/apps/legacy\n+++ /dev/null\n@@ -1 +0,0 @@\n-kind: Service\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			if err := writeUnifiedDiffs(&out, []server.FluxDiffResult{tt.result}); err != nil {
+				t.Fatalf("writeUnifiedDiffs() error = %v", err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("writeUnifiedDiffs() =\n%s\nwant\n%s", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestRunDiffUsage(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{name: "help", args: []string{"--help"}, want: diffExitNoChanges},
+		{name: "no kustomization", args: nil, want: diffExitError},
+		{name: "kustomization without a namespace", args: []string{"--kustomization", "apps"}, want: diffExitError},
+		{name: "unknown output", args: []string{"--kustomization", "flux-system/apps", "-o", "yaml"}, want: diffExitError},
+		{name: "unknown flag", args: []string{"--kustomization", "flux-system/apps", "--prune"}, want: diffExitError},
+		{name: "local source as a separate argument", args: []string{"--kustomization", "flux-system/apps", "--local-source", "./repo"}, want: diffExitError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := runDiff(tt.args); got != tt.want {
+				t.Errorf("runDiff(%q) = %d, want %d", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestArchiveDirectory(t *testing.T) {
+	src := t.TempDir()
+	writeFiles(t, src, map[string]string{
+		"clusters/prod/kustomization.yaml": "resources:\n- ../../apps\n",
+		"apps/web.yaml":                    "kind: Deployment\n",
+		"README.md":                        "infra\n",
+		".git/HEAD":                        "ref: refs/heads/main\n",
+	})
+	if err := os.Symlink("apps/web.yaml", filepath.Join(src, "web.yaml")); err != nil {
+		t.Fatal(err)
+	}
+
+	archive, err := archiveDirectory(src, server.MaxLocalSourceSize)
+	if err != nil {
+		t.Fatalf("archiveDirectory() error = %v", err)
+	}
+
+	dest := t.TempDir()
+	if _, err := server.ExtractTarGz(archive, dest); err != nil {
+		t.Fatalf("ExtractTarGz() error = %v", err)
+	}
+	for name, want := range map[string]string{
+		"clusters/prod/kustomization.yaml": "resources:\n- ../../apps\n",
+		"apps/web.yaml":                    "kind: Deployment\n",
+		"README.md":                        "infra\n",
+	} {
+		got, err := os.ReadFile(filepath.Join(dest, name))
+		if err != nil || string(got) != want {
+			t.Errorf("%s = %q, %v, want %q", name, got, err, want)
+		}
+	}
+	for _, name := range []string{".git", "web.yaml"} {
+		if _, err := os.Lstat(filepath.Join(dest, name)); !os.IsNotExist(err) {
+			t.Errorf("%s was archived, want it left out", name)
+		}
+	}
+}
+
+func TestArchiveDirectoryTooLarge(t *testing.T) {
+	src := t.TempDir()
+	// Random content gzip can't shrink below the limit
+	content := make([]byte, 64<<10)
+	rand.New(rand.NewSource(1)).Read(content)
+	writeFiles(t, src, map[string]string{"big.bin": string(content)})
+
+	_, err := archiveDirectory(src, 1024)
+	if err == nil || !strings.Contains(err.Error(), "local source is larger than") {
+		t.Fatalf("archiveDirectory() error = %v, want the size limit", err)
+	}
+}
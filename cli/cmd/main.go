@@ -45,9 +45,14 @@ func main() {
 	log.Print(server.CapacitorBanner)
 
 	// Handle subcommands
-	if len(os.Args) > 1 && os.Args[1] == "version" {
-		fmt.Println(version)
-		return
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "version":
+			fmt.Println(version)
+			return
+		case "diff":
+			os.Exit(runDiff(os.Args[2:]))
+		}
 	}
 
 	// Load configuration
@@ -94,13 +99,18 @@ func main() {
 
 	log.Printf("Server started on %s:%d", cfg.Address, cfg.Port)
 
-	// Open browser
-	serverURL := fmt.Sprintf("http://%s:%d", cfg.Address, cfg.Port)
+	// Open browser. The URL carries the session token, the server exchanges it for a cookie.
+	serverURL := fmt.Sprintf("http://%s:%d/?%s=%s", cfg.Address, cfg.Port, server.AuthTokenQueryParam, srv.AuthToken())
 	log.Printf("Opening browser at %s", serverURL)
+	log.Printf("If the browser doesn't open, or you open Capacitor in another browser, use the URL above")
 	if err := openBrowser(serverURL); err != nil {
 		log.Printf("Warning: Could not open browser: %v", err)
 	}
 
+	if cfg.ReadOnly {
+		log.Printf("Read-only mode: all mutating operations are disabled")
+	}
+
 	if cfg.InsecureSkipTLSVerify {
 		log.Printf("WARNING: TLS certificate verification is disabled. This is insecure and should only be used for development.")
 	}
@@ -19,6 +19,7 @@ require (
 	github.com/labstack/echo/v4 v4.14.0
 	github.com/lucasb-eyer/go-colorful v1.3.0
 	github.com/onsi/gomega v1.38.2
+	github.com/pmezard/go-difflib v1.0.1-0.20181226105442-5d4384ee4fb2
 	github.com/theckman/yacspin v0.13.12
 	helm.sh/helm/v3 v3.19.4
 	k8s.io/apimachinery v0.34.3
@@ -58,7 +59,6 @@ require (
 	github.com/mitchellh/go-ps v1.0.0 // indirect
 	github.com/mitchellh/hashstructure v1.1.0 // indirect
 	github.com/olekukonko/tablewriter v0.0.5 // indirect
-	github.com/pmezard/go-difflib v1.0.1-0.20181226105442-5d4384ee4fb2 // indirect
 	github.com/santhosh-tekuri/jsonschema/v6 v6.0.2 // indirect
 	github.com/sergi/go-diff v1.4.0 // indirect
 	github.com/texttheater/golang-levenshtein v1.0.1 // indirect
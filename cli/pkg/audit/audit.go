@@ -0,0 +1,298 @@
+// Copyright 2025 Laszlo Consulting Kft.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package audit records mutating actions taken through Capacitor
+// in a size-rotated JSON Lines file, and lets them be queried back.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Outcomes of an audited action
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+	OutcomeDenied  = "denied"
+)
+
+// Target identifies the object an action was taken on
+type Target struct {
+	Kind      string `json:"kind,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name,omitempty"`
+	// Resource and Path are set for requests made through the Kubernetes API proxy,
+	// where only the plural resource name is known, e.g. "deployments"
+	Resource string `json:"resource,omitempty"`
+	Path     string `json:"path,omitempty"`
+}
+
+// Entry is a single audit record
+type Entry struct {
+	Time time.Time `json:"time"`
+	// User is the kubeconfig user the action was performed as
+	User string `json:"user"`
+	// OSUser is the operating system user running Capacitor
+	OSUser     string            `json:"osUser,omitempty"`
+	RemoteAddr string            `json:"remoteAddr,omitempty"`
+	Context    string            `json:"context"`
+	Verb       string            `json:"verb"`
+	Target     Target            `json:"target"`
+	Details    map[string]string `json:"details,omitempty"`
+	Status     int               `json:"status"`
+	Outcome    string            `json:"outcome"`
+	Error      string            `json:"error,omitempty"`
+	DurationMs int64             `json:"durationMs"`
+}
+
+// Filter selects entries in Query. Zero values match everything.
+type Filter struct {
+	Since   time.Time
+	Until   time.Time
+	User    string
+	Context string
+	Verb    string
+	// Kind matches the target kind, or the plural resource name for proxy requests
+	Kind      string
+	Namespace string
+	Name      string
+	Outcome   string
+	// Limit caps the number of returned entries, newest first. Zero means no limit.
+	Limit int
+}
+
+// Matches reports whether the entry satisfies the filter
+func (f Filter) Matches(e Entry) bool {
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && e.Time.After(f.Until) {
+		return false
+	}
+	return matchField(f.User, e.User, e.OSUser) &&
+		matchField(f.Context, e.Context) &&
+		matchField(f.Verb, e.Verb) &&
+		matchField(f.Kind, e.Target.Kind, e.Target.Resource) &&
+		matchField(f.Namespace, e.Target.Namespace) &&
+		matchField(f.Name, e.Target.Name) &&
+		matchField(f.Outcome, e.Outcome)
+}
+
+// matchField does a case-insensitive comparison against any of the values; an empty want matches everything
+func matchField(want string, values ...string) bool {
+	if want == "" {
+		return true
+	}
+	for _, v := range values {
+		if strings.EqualFold(want, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// Log appends entries to a JSON Lines file and rotates it when it grows past maxSize.
+// Rotated files are kept as path.1 (newest) to path.N (oldest).
+// A nil *Log is valid and discards everything, so callers don't need to check whether auditing is enabled.
+type Log struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+// New opens (or creates) the audit log at path
+func New(path string, maxSize int64, maxBackups int) (*Log, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+
+	l := &Log{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+	}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Path returns the location of the active audit log file
+func (l *Log) Path() string {
+	if l == nil {
+		return ""
+	}
+	return l.path
+}
+
+// open opens the active file for appending
+func (l *Log) open() error {
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to stat audit log: %w", err)
+	}
+	l.file = f
+	l.size = info.Size()
+	return nil
+}
+
+// Record appends an entry to the log
+func (l *Log) Record(e Entry) error {
+	if l == nil {
+		return nil
+	}
+
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return fmt.Errorf("audit log is closed")
+	}
+
+	if l.maxSize > 0 && l.size > 0 && l.size+int64(len(line)) > l.maxSize {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := l.file.Write(line)
+	l.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write audit entry: %w", err)
+	}
+	return nil
+}
+
+// rotate shifts path.N-1 to path.N, the active file to path.1, and starts a new active file
+func (l *Log) rotate() error {
+	if err := l.file.Close(); err != nil {
+		return fmt.Errorf("failed to close audit log: %w", err)
+	}
+	l.file = nil
+
+	if l.maxBackups <= 0 {
+		if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove audit log: %w", err)
+		}
+		return l.open()
+	}
+
+	_ = os.Remove(l.backupPath(l.maxBackups))
+	for i := l.maxBackups - 1; i >= 1; i-- {
+		if err := os.Rename(l.backupPath(i), l.backupPath(i+1)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to rotate audit log: %w", err)
+		}
+	}
+	if err := os.Rename(l.path, l.backupPath(1)); err != nil {
+		return fmt.Errorf("failed to rotate audit log: %w", err)
+	}
+	return l.open()
+}
+
+func (l *Log) backupPath(i int) string {
+	return fmt.Sprintf("%s.%d", l.path, i)
+}
+
+// Query returns the entries matching the filter, newest first.
+// It reads the rotated files as well as the active one.
+func (l *Log) Query(f Filter) ([]Entry, error) {
+	if l == nil {
+		return []Entry{}, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	files := []string{l.path}
+	for i := 1; i <= l.maxBackups; i++ {
+		files = append(files, l.backupPath(i))
+	}
+
+	entries := []Entry{}
+	for _, path := range files {
+		matched, err := readEntries(path, f)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, matched...)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time.After(entries[j].Time)
+	})
+	if f.Limit > 0 && len(entries) > f.Limit {
+		entries = entries[:f.Limit]
+	}
+	return entries, nil
+}
+
+// readEntries reads the matching entries of a single file. Missing files and malformed lines are skipped.
+func readEntries(path string, f Filter) ([]Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		if f.Matches(e) {
+			entries = append(entries, e)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// Close closes the active file
+func (l *Log) Close() error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
@@ -0,0 +1,103 @@
+// Copyright 2025 Laszlo Consulting Kft.
+// SPDX-License-Identifier: Apache-2.0
+
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLogRotationAndQuery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	l, err := New(path, 400, 2)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer l.Close()
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 10; i++ {
+		verb := "scale"
+		if i%2 == 0 {
+			verb = "reconcile"
+		}
+		err := l.Record(Entry{
+			Time:    start.Add(time.Duration(i) * time.Minute),
+			User:    "admin",
+			Context: "prod",
+			Verb:    verb,
+			Target:  Target{Kind: "Deployment", Namespace: "default", Name: "web"},
+			Outcome: OutcomeSuccess,
+		})
+		if err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+
+	if _, err := os.Stat(path + ".1"); err != nil {
+		t.Errorf("expected a rotated file: %v", err)
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("expected at most 2 rotated files, got err = %v", err)
+	}
+
+	all, err := l.Query(Filter{})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(all) == 0 || !all[0].Time.Equal(start.Add(9*time.Minute)) {
+		t.Fatalf("Query() should return the newest entry first, got %v", all)
+	}
+
+	scales, err := l.Query(Filter{Verb: "SCALE", Limit: 2})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(scales) != 2 {
+		t.Fatalf("Query() returned %d entries, want 2", len(scales))
+	}
+	for _, e := range scales {
+		if e.Verb != "scale" {
+			t.Errorf("Query() returned verb %q, want scale", e.Verb)
+		}
+	}
+}
+
+func TestFilterMatches(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	entry := Entry{
+		Time:    now,
+		User:    "admin@prod",
+		OSUser:  "laszlo",
+		Context: "prod",
+		Verb:    "helm.rollback",
+		Target:  Target{Kind: "HelmRelease", Namespace: "apps", Name: "api"},
+		Outcome: OutcomeFailure,
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   bool
+	}{
+		{"empty filter", Filter{}, true},
+		{"kubeconfig user", Filter{User: "admin@prod"}, true},
+		{"os user", Filter{User: "laszlo"}, true},
+		{"other context", Filter{Context: "staging"}, false},
+		{"namespace and name", Filter{Namespace: "apps", Name: "api"}, true},
+		{"outcome", Filter{Outcome: OutcomeSuccess}, false},
+		{"since before", Filter{Since: now.Add(-time.Hour)}, true},
+		{"since after", Filter{Since: now.Add(time.Hour)}, false},
+		{"until before", Filter{Until: now.Add(-time.Hour)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Matches(entry); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
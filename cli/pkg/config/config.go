@@ -20,10 +20,32 @@ type Config struct {
 	StaticFilesDirectory string
 	AccessLogEnabled     bool
 
+	// ReadOnly makes the server refuse every mutating operation:
+	// Flux actions, scale, rollout restart, CronJob runs, Helm rollback, upgrade, uninstall and test runs, exec, node debug,
+	// and any non-GET/HEAD request through the Kubernetes API proxy.
+	ReadOnly bool
+
+	// WatchMaxUpdateRate is the maximum number of updates per second sent to the browser for a single object.
+	// Updates in between are merged, so busy objects like Leases don't flood the UI. Zero means no limit.
+	WatchMaxUpdateRate float64
+
+	// Audit log settings. Mutating actions are recorded to AuditLogPath as JSON Lines,
+	// rotated when the file grows past AuditLogMaxSizeMB. An empty path disables auditing.
+	AuditLogPath       string
+	AuditLogMaxSizeMB  int
+	AuditLogMaxBackups int
+
 	// Kubernetes settings
 	KubeConfigPath        string
 	InsecureSkipTLSVerify bool
 
+	// Helm storage settings. HelmDriver is where Helm keeps releases, secret, configmap or sql,
+	// like HELM_DRIVER. HelmDriverByContext overrides it for single kubeconfig contexts.
+	// The sql driver connects to HelmSQLConnectionString, like HELM_DRIVER_SQL_CONNECTION_STRING.
+	HelmDriver              string
+	HelmDriverByContext     map[string]string
+	HelmSQLConnectionString string
+
 	// FluxCD controller settings (used for logs and controller discovery)
 	FluxCD FluxCDConfig
 
@@ -71,8 +93,16 @@ func New() *Config {
 		Port:                  4739,
 		StaticFilesDirectory:  "./web/static",
 		AccessLogEnabled:      false,
+		AuditLogPath:          defaultAuditLogPath(),
+		AuditLogMaxSizeMB:     10,
+		AuditLogMaxBackups:    5,
+		WatchMaxUpdateRate:    0,
 		KubeConfigPath:        defaultKubeConfigPath(),
 		InsecureSkipTLSVerify: false,
+
+		HelmDriver:              envOrDefault("HELM_DRIVER", "secret"),
+		HelmDriverByContext:     map[string]string{},
+		HelmSQLConnectionString: os.Getenv("HELM_DRIVER_SQL_CONNECTION_STRING"),
 		FluxCD: FluxCDConfig{
 			Namespace:                         "flux-system",
 			HelmControllerDeploymentName:      "helm-controller",
@@ -100,6 +130,14 @@ func (c *Config) Parse() {
 	pflag.StringVar(&c.KubeConfigPath, "kubeconfig", c.KubeConfigPath, "Path to kubeconfig file (KUBECONFIG)")
 	pflag.BoolVar(&c.InsecureSkipTLSVerify, "insecure-skip-tls-verify", c.InsecureSkipTLSVerify, "Skip TLS certificate verification (insecure, use only for development) (KUBECONFIG_INSECURE_SKIP_TLS_VERIFY)")
 	pflag.BoolVar(&c.AccessLogEnabled, "access-log", c.AccessLogEnabled, "Enable HTTP/WebSocket access logging (ACCESS_LOG_ENABLED)")
+	pflag.StringVar(&c.AuditLogPath, "audit-log", c.AuditLogPath, "Path to the audit log of mutating actions, empty to disable (CAPACITOR_NEXT_AUDIT_LOG)")
+	pflag.IntVar(&c.AuditLogMaxSizeMB, "audit-log-max-size", c.AuditLogMaxSizeMB, "Size in megabytes at which the audit log is rotated (CAPACITOR_NEXT_AUDIT_LOG_MAX_SIZE)")
+	pflag.IntVar(&c.AuditLogMaxBackups, "audit-log-max-backups", c.AuditLogMaxBackups, "Number of rotated audit log files to keep (CAPACITOR_NEXT_AUDIT_LOG_MAX_BACKUPS)")
+	pflag.Float64Var(&c.WatchMaxUpdateRate, "watch-max-update-rate", c.WatchMaxUpdateRate, "Maximum updates per second sent to the browser for a single object, 0 for no limit (CAPACITOR_NEXT_WATCH_MAX_UPDATE_RATE)")
+	pflag.StringVar(&c.HelmDriver, "helm-driver", c.HelmDriver, "Storage driver Helm keeps releases with: secret, configmap or sql (CAPACITOR_NEXT_HELM_DRIVER, HELM_DRIVER)")
+	pflag.StringToStringVar(&c.HelmDriverByContext, "helm-driver-context", c.HelmDriverByContext, "Helm storage driver of single contexts, e.g. local=sql,legacy=configmap (CAPACITOR_NEXT_HELM_DRIVER_CONTEXTS)")
+	pflag.StringVar(&c.HelmSQLConnectionString, "helm-sql-connection-string", c.HelmSQLConnectionString, "Connection string of the sql Helm storage driver (CAPACITOR_NEXT_HELM_SQL_CONNECTION_STRING, HELM_DRIVER_SQL_CONNECTION_STRING)")
+	pflag.BoolVar(&c.ReadOnly, "read-only", c.ReadOnly, "Refuse all mutating operations, for look-but-don't-touch access (CAPACITOR_NEXT_READ_ONLY)")
 
 	pflag.Parse()
 
@@ -127,6 +165,52 @@ func (c *Config) Parse() {
 		}
 	}
 
+	// CAPACITOR_NEXT_READ_ONLY can be set to "false" or "0" to disable it.
+	if v := os.Getenv("CAPACITOR_NEXT_READ_ONLY"); v != "" {
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "true", "1":
+			c.ReadOnly = true
+		default:
+			c.ReadOnly = false
+		}
+	}
+
+	if env, ok := os.LookupEnv("CAPACITOR_NEXT_AUDIT_LOG"); ok {
+		c.AuditLogPath = env
+	}
+	if env := os.Getenv("CAPACITOR_NEXT_AUDIT_LOG_MAX_SIZE"); env != "" {
+		if size, err := strconv.Atoi(env); err == nil {
+			c.AuditLogMaxSizeMB = size
+		}
+	}
+	if env := os.Getenv("CAPACITOR_NEXT_AUDIT_LOG_MAX_BACKUPS"); env != "" {
+		if backups, err := strconv.Atoi(env); err == nil {
+			c.AuditLogMaxBackups = backups
+		}
+	}
+
+	if env := os.Getenv("CAPACITOR_NEXT_WATCH_MAX_UPDATE_RATE"); env != "" {
+		if rate, err := strconv.ParseFloat(env, 64); err == nil {
+			c.WatchMaxUpdateRate = rate
+		}
+	}
+
+	if env := os.Getenv("CAPACITOR_NEXT_HELM_DRIVER"); env != "" {
+		c.HelmDriver = env
+	}
+	// CAPACITOR_NEXT_HELM_DRIVER_CONTEXTS is a comma-separated list of context=driver pairs
+	if env := os.Getenv("CAPACITOR_NEXT_HELM_DRIVER_CONTEXTS"); env != "" {
+		for _, pair := range strings.Split(env, ",") {
+			contextName, driver, ok := strings.Cut(pair, "=")
+			if ok && strings.TrimSpace(contextName) != "" {
+				c.HelmDriverByContext[strings.TrimSpace(contextName)] = strings.TrimSpace(driver)
+			}
+		}
+	}
+	if env := os.Getenv("CAPACITOR_NEXT_HELM_SQL_CONNECTION_STRING"); env != "" {
+		c.HelmSQLConnectionString = env
+	}
+
 	if env := os.Getenv("KUBECONFIG"); env != "" {
 		c.KubeConfigPath = env
 	}
@@ -172,6 +256,22 @@ func (c *Config) Parse() {
 	}
 }
 
+// HelmDriverFor returns the Helm storage driver of a kubeconfig context
+func (c *Config) HelmDriverFor(contextName string) string {
+	if driver, ok := c.HelmDriverByContext[contextName]; ok && driver != "" {
+		return driver
+	}
+	return c.HelmDriver
+}
+
+// envOrDefault returns the value of an environment variable, or def if it's not set
+func envOrDefault(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
 // defaultKubeConfigPath returns the default path to the kubeconfig file
 func defaultKubeConfigPath() string {
 	if home := homeDir(); home != "" {
@@ -180,6 +280,14 @@ func defaultKubeConfigPath() string {
 	return ""
 }
 
+// defaultAuditLogPath returns the default path of the audit log
+func defaultAuditLogPath() string {
+	if home := homeDir(); home != "" {
+		return filepath.Join(home, ".capacitor", "audit.jsonl")
+	}
+	return ""
+}
+
 // homeDir returns the user's home directory
 func homeDir() string {
 	if h := os.Getenv("HOME"); h != "" {
@@ -0,0 +1,123 @@
+// Copyright 2025 Laszlo Consulting Kft.
+// SPDX-License-Identifier: Apache-2.0
+
+package helm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+// readmeFileNames are the README names `helm show readme` looks for, in order of preference
+var readmeFileNames = []string{"readme.md", "readme.txt", "readme"}
+
+// ChartInfo is the chart stored with a release revision.
+// Helm doesn't store subcharts with a release, so dependencies are listed but their content isn't available.
+type ChartInfo struct {
+	Metadata     *chart.Metadata        `json:"metadata"` // Chart.yaml
+	Readme       string                 `json:"readme,omitempty"`
+	Values       map[string]interface{} `json:"values"`           // the chart's default values.yaml
+	Schema       json.RawMessage        `json:"schema,omitempty"` // values.schema.json
+	Templates    []string               `json:"templates"`
+	Dependencies []*chart.Dependency    `json:"dependencies"`
+	Lock         *chart.Lock            `json:"lock,omitempty"` // Chart.lock
+}
+
+// ChartFile is a file of a chart with its content
+type ChartFile struct {
+	Name string `json:"name"`
+	Data string `json:"data"`
+}
+
+// GetChart returns the chart a release revision was installed from. Revision 0 means the latest revision.
+func (c *Client) GetChart(ctx context.Context, name, namespace string, revision int) (*ChartInfo, error) {
+	rel, err := c.releaseRevision(name, namespace, revision)
+	if err != nil {
+		return nil, err
+	}
+	ch := rel.Chart
+
+	info := &ChartInfo{
+		Metadata:     ch.Metadata,
+		Readme:       chartReadme(ch),
+		Values:       ch.Values,
+		Templates:    make([]string, 0, len(ch.Templates)),
+		Dependencies: ch.Metadata.Dependencies,
+		Lock:         ch.Lock,
+	}
+	if info.Values == nil {
+		info.Values = map[string]interface{}{}
+	}
+	if info.Dependencies == nil {
+		info.Dependencies = []*chart.Dependency{}
+	}
+	if len(ch.Schema) > 0 && json.Valid(ch.Schema) {
+		info.Schema = ch.Schema
+	}
+	for _, tpl := range ch.Templates {
+		info.Templates = append(info.Templates, tpl.Name)
+	}
+	sort.Strings(info.Templates)
+	return info, nil
+}
+
+// GetChartTemplates returns the template sources of the chart of a release revision,
+// all of them or the one named file, e.g. templates/deployment.yaml
+func (c *Client) GetChartTemplates(ctx context.Context, name, namespace string, revision int, file string) ([]ChartFile, error) {
+	rel, err := c.releaseRevision(name, namespace, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	templates := make([]ChartFile, 0, len(rel.Chart.Templates))
+	for _, tpl := range rel.Chart.Templates {
+		if file != "" && tpl.Name != file {
+			continue
+		}
+		templates = append(templates, ChartFile{Name: tpl.Name, Data: string(tpl.Data)})
+	}
+	if file != "" && len(templates) == 0 {
+		return nil, fmt.Errorf("chart of release %s has no template %s", name, file)
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+	return templates, nil
+}
+
+// releaseRevision returns a revision of a release with its chart, 0 means the latest revision
+func (c *Client) releaseRevision(name, namespace string, revision int) (*release.Release, error) {
+	actionConfig, err := c.actionConfigFor(namespace)
+	if err != nil {
+		return nil, err
+	}
+	client := action.NewGet(actionConfig)
+	client.Version = revision
+
+	rel, err := client.Run(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get release %s: %w", name, err)
+	}
+	if rel.Chart == nil || rel.Chart.Metadata == nil {
+		return nil, fmt.Errorf("release %s has no chart stored", name)
+	}
+	return rel, nil
+}
+
+// chartReadme returns the README of a chart from its top-level files
+func chartReadme(ch *chart.Chart) string {
+	for _, readme := range readmeFileNames {
+		for _, f := range ch.Files {
+			if path.Dir(f.Name) == "." && strings.EqualFold(f.Name, readme) {
+				return string(f.Data)
+			}
+		}
+	}
+	return ""
+}
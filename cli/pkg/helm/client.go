@@ -7,23 +7,37 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"sync"
 	"time"
 
 	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chartutil"
 	"helm.sh/helm/v3/pkg/cli"
 	"helm.sh/helm/v3/pkg/release"
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/restmapper"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
-// Client wraps the Helm client with additional functionality
+// Client wraps the Helm client with additional functionality.
+// It keeps an initialised action configuration per namespace, and every configuration shares
+// one cached discovery client and RESTMapper. A Client is meant to live as long as its context.
+// Configurations are shared by concurrent actions, so they are never modified once created:
+// their Capabilities are discovered up front, rather than lazily by the first action.
 type Client struct {
-	actionConfig *action.Configuration
-	settings     *cli.EnvSettings
+	config    *rest.Config
+	settings  *cli.EnvSettings
+	storage   Storage
+	discovery discovery.CachedDiscoveryInterface
+	mapper    *restmapper.DeferredDiscoveryRESTMapper
+	dynamic   dynamic.Interface
+
+	mu      sync.Mutex
+	configs map[string]*action.Configuration // keyed by namespace
 }
 
 // Release represents a Helm release with additional metadata
@@ -51,8 +65,13 @@ type HistoryRelease struct {
 	Description string `json:"description"`
 }
 
-// NewClient creates a new Helm client
-func NewClient(kubeConfig *rest.Config, namespace string) (*Client, error) {
+// NewClient creates a new Helm client that reads and writes releases in the given storage
+func NewClient(kubeConfig *rest.Config, namespace string, storage Storage) (*Client, error) {
+	storage, err := storage.validate()
+	if err != nil {
+		return nil, err
+	}
+
 	// Create Helm settings
 	settings := cli.New()
 
@@ -61,45 +80,111 @@ func NewClient(kubeConfig *rest.Config, namespace string) (*Client, error) {
 		settings.SetNamespace(namespace)
 	}
 
-	// Create action configuration
-	actionConfig := new(action.Configuration)
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(kubeConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create discovery client: %w", err)
+	}
+	cachedDiscovery := memory.NewMemCacheClient(discoveryClient)
+	dynamicClient, err := dynamic.NewForConfig(kubeConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
+	}
 
-	// Initialize with the provided kubeconfig
-	if err := actionConfig.Init(
-		&restClientGetter{config: kubeConfig, namespace: namespace},
-		settings.Namespace(),
-		"secret",
-		log.Printf,
-	); err != nil {
-		return nil, fmt.Errorf("failed to initialize Helm action config: %w", err)
+	c := &Client{
+		config:    kubeConfig,
+		settings:  settings,
+		storage:   storage,
+		discovery: cachedDiscovery,
+		mapper:    restmapper.NewDeferredDiscoveryRESTMapper(cachedDiscovery),
+		dynamic:   dynamicClient,
+		configs:   make(map[string]*action.Configuration),
 	}
 
-	return &Client{
-		actionConfig: actionConfig,
-		settings:     settings,
-	}, nil
+	// Initialize the configuration of the default namespace right away, so errors surface here
+	if _, err := c.actionConfigFor(settings.Namespace()); err != nil {
+		return nil, err
+	}
+	return c, nil
 }
 
-// ListReleases lists all Helm releases in the configured namespace
-func (c *Client) ListReleases(ctx context.Context, namespace string) ([]*Release, error) {
-	// Create a new action configuration for this specific request
-	actionConfig := new(action.Configuration)
+// actionConfigFor returns the action configuration of a namespace, initialising it on first use
+func (c *Client) actionConfigFor(namespace string) (*action.Configuration, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	// Create a client getter that uses the specified namespace
+	if actionConfig, ok := c.configs[namespace]; ok {
+		return actionConfig, nil
+	}
+
+	actionConfig := new(action.Configuration)
 	getter := &restClientGetter{
-		config:    c.actionConfig.RESTClientGetter.(*restClientGetter).config,
+		config:    c.config,
 		namespace: namespace,
+		discovery: c.discovery,
+		mapper:    c.mapper,
+	}
+	if err := c.storage.initActionConfig(actionConfig, getter, namespace); err != nil {
+		return nil, fmt.Errorf("failed to initialize Helm action config with namespace %s: %w", namespace, err)
+	}
+	capabilities, err := c.capabilities()
+	if err != nil {
+		return nil, err
 	}
+	actionConfig.Capabilities = capabilities
+
+	c.configs[namespace] = actionConfig
+	return actionConfig, nil
+}
+
+// Driver returns the storage driver the client keeps releases with
+func (c *Client) Driver() string {
+	return c.storage.Driver
+}
 
-	// Initialize the action config with the namespace
-	err := actionConfig.Init(
-		getter,
-		namespace,
-		"secret",
-		log.Printf,
-	)
+// capabilities discovers the Kubernetes version and API versions of the cluster, the way Helm does
+// when an action configuration has no Capabilities yet
+func (c *Client) capabilities() (*chartutil.Capabilities, error) {
+	kubeVersion, err := c.discovery.ServerVersion()
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialize Helm action config with namespace %s: %w", namespace, err)
+		return nil, fmt.Errorf("could not get server version from Kubernetes: %w", err)
+	}
+
+	// Like Helm, tolerate API groups that fail discovery, e.g. an unavailable metrics server
+	apiVersions, err := action.GetVersionSet(c.discovery)
+	if err != nil {
+		if !discovery.IsGroupDiscoveryFailedError(err) {
+			return nil, fmt.Errorf("could not get apiVersions from Kubernetes: %w", err)
+		}
+		log.Printf("Warning: the Kubernetes server has an orphaned API service: %v", err)
+	}
+
+	return &chartutil.Capabilities{
+		APIVersions: apiVersions,
+		KubeVersion: chartutil.KubeVersion{
+			Version: kubeVersion.GitVersion,
+			Major:   kubeVersion.Major,
+			Minor:   kubeVersion.Minor,
+		},
+		HelmVersion: chartutil.DefaultCapabilities.HelmVersion,
+	}, nil
+}
+
+// InvalidateDiscovery drops the cached API discovery and RESTMapper, and the action configurations
+// with the Capabilities discovered from them, so the next action sees CRDs that were added or removed since.
+// Actions already running keep the configuration they started with.
+func (c *Client) InvalidateDiscovery() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.mapper.Reset()
+	c.configs = make(map[string]*action.Configuration)
+}
+
+// ListReleases lists all Helm releases in the configured namespace
+func (c *Client) ListReleases(ctx context.Context, namespace string) ([]*Release, error) {
+	actionConfig, err := c.actionConfigFor(namespace)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create a list action configuration using our namespace-specific action config
@@ -130,7 +215,11 @@ func (c *Client) ListReleases(ctx context.Context, namespace string) ([]*Release
 
 // GetRelease gets a specific Helm release
 func (c *Client) GetRelease(ctx context.Context, name string) (*Release, error) {
-	client := action.NewGet(c.actionConfig)
+	actionConfig, err := c.actionConfigFor(c.settings.Namespace())
+	if err != nil {
+		return nil, err
+	}
+	client := action.NewGet(actionConfig)
 
 	rel, err := client.Run(name)
 	if err != nil {
@@ -142,24 +231,9 @@ func (c *Client) GetRelease(ctx context.Context, name string) (*Release, error)
 
 // GetHistory retrieves the release history for a Helm release
 func (c *Client) GetHistory(ctx context.Context, name, namespace string) ([]HistoryRelease, error) {
-	// Create a new action configuration for this specific request
-	actionConfig := new(action.Configuration)
-
-	// Create a client getter that uses the specified namespace
-	getter := &restClientGetter{
-		config:    c.actionConfig.RESTClientGetter.(*restClientGetter).config,
-		namespace: namespace,
-	}
-
-	// Initialize the action config with the namespace
-	err := actionConfig.Init(
-		getter,
-		namespace,
-		"secret",
-		log.Printf,
-	)
+	actionConfig, err := c.actionConfigFor(namespace)
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialize Helm action config with namespace %s: %w", namespace, err)
+		return nil, err
 	}
 
 	// Create a history action
@@ -194,24 +268,9 @@ func (c *Client) GetHistory(ctx context.Context, name, namespace string) ([]Hist
 func (c *Client) GetValues(ctx context.Context, name, namespace string, allValues bool, revision int) (map[string]interface{}, error) {
 	log.Printf("GetValues called for release %s in namespace %s, allValues=%v, revision=%d", name, namespace, allValues, revision)
 
-	// Create a new action configuration for this specific request
-	actionConfig := new(action.Configuration)
-
-	// Create a client getter that uses the specified namespace
-	getter := &restClientGetter{
-		config:    c.actionConfig.RESTClientGetter.(*restClientGetter).config,
-		namespace: namespace,
-	}
-
-	// Initialize the action config with the namespace
-	err := actionConfig.Init(
-		getter,
-		namespace,
-		"secret",
-		log.Printf,
-	)
+	actionConfig, err := c.actionConfigFor(namespace)
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialize Helm action config with namespace %s: %w", namespace, err)
+		return nil, err
 	}
 
 	// Create a get values action
@@ -233,24 +292,9 @@ func (c *Client) GetValues(ctx context.Context, name, namespace string, allValue
 func (c *Client) GetManifest(ctx context.Context, name, namespace string, revision int) (string, error) {
 	log.Printf("GetManifest called for release %s in namespace %s, revision=%d", name, namespace, revision)
 
-	// Create a new action configuration for this specific request
-	actionConfig := new(action.Configuration)
-
-	// Create a client getter that uses the specified namespace
-	getter := &restClientGetter{
-		config:    c.actionConfig.RESTClientGetter.(*restClientGetter).config,
-		namespace: namespace,
-	}
-
-	// Initialize the action config with the namespace
-	err := actionConfig.Init(
-		getter,
-		namespace,
-		"secret",
-		log.Printf,
-	)
+	actionConfig, err := c.actionConfigFor(namespace)
 	if err != nil {
-		return "", fmt.Errorf("failed to initialize Helm action config with namespace %s: %w", namespace, err)
+		return "", err
 	}
 
 	// Create a get manifest action
@@ -272,28 +316,32 @@ func (c *Client) GetManifest(ctx context.Context, name, namespace string, revisi
 	return rel.Manifest, nil
 }
 
-// Rollback rolls back a Helm release to a specific revision
-func (c *Client) Rollback(ctx context.Context, name, namespace string, revision int) error {
-	log.Printf("Rollback called for release %s in namespace %s to revision %d", name, namespace, revision)
+// RollbackOptions are the flags of `helm rollback`
+type RollbackOptions struct {
+	Force         bool
+	CleanupOnFail bool
+	DisableHooks  bool
+	Timeout       time.Duration // zero means five minutes
+}
 
-	// Create a new action configuration for this specific request
-	actionConfig := new(action.Configuration)
+// Rollback rolls back a Helm release to a specific revision and waits for its resources to become ready.
+// While it runs, progress receives the status changes of the release and the readiness of its resources.
+func (c *Client) Rollback(ctx context.Context, name, namespace string, revision int, opts RollbackOptions, progress func(JobEvent)) error {
+	log.Printf("Rollback called for release %s in namespace %s to revision %d", name, namespace, revision)
 
-	// Create a client getter that uses the specified namespace
-	getter := &restClientGetter{
-		config:    c.actionConfig.RESTClientGetter.(*restClientGetter).config,
-		namespace: namespace,
+	actionConfig, err := c.actionConfigFor(namespace)
+	if err != nil {
+		return err
 	}
 
-	// Initialize the action config with the namespace
-	err := actionConfig.Init(
-		getter,
-		namespace,
-		"secret",
-		log.Printf,
-	)
+	// The manifest of the target revision tells which resources to follow
+	target, err := actionConfig.Releases.Get(name, revision)
+	if err != nil {
+		return fmt.Errorf("failed to get revision %d of release %s: %w", revision, name, err)
+	}
+	objects, err := c.manifestObjects(target.Manifest, namespace)
 	if err != nil {
-		return fmt.Errorf("failed to initialize Helm action config with namespace %s: %w", namespace, err)
+		return err
 	}
 
 	// Create a rollback action
@@ -301,9 +349,27 @@ func (c *Client) Rollback(ctx context.Context, name, namespace string, revision
 	client.Version = revision
 	client.Wait = true
 	client.Timeout = 300 * time.Second // 5 minute timeout for rollback
+	if opts.Timeout > 0 {
+		client.Timeout = opts.Timeout
+	}
+	client.Force = opts.Force
+	client.CleanupOnFail = opts.CleanupOnFail
+	client.DisableHooks = opts.DisableHooks
+
+	reporter := newProgressReporter(c, actionConfig, name, objects, progress)
+	watchCtx, stopWatching := context.WithCancel(ctx)
+	watched := make(chan struct{})
+	go func() {
+		defer close(watched)
+		reporter.run(watchCtx)
+	}()
 
 	// Execute the rollback
 	err = client.Run(name)
+	stopWatching()
+	<-watched
+	reporter.report(ctx) // the final status
+
 	if err != nil {
 		log.Printf("Error rolling back Helm release: %v", err)
 		return fmt.Errorf("failed to rollback release %s to revision %d: %w", name, revision, err)
@@ -357,10 +423,13 @@ func convertRelease(rel *release.Release) *Release {
 	}
 }
 
-// restClientGetter implements genericclioptions.RESTClientGetter
+// restClientGetter implements genericclioptions.RESTClientGetter.
+// The discovery client and RESTMapper are shared by every namespace of a Client.
 type restClientGetter struct {
 	config    *rest.Config
 	namespace string
+	discovery discovery.CachedDiscoveryInterface
+	mapper    meta.RESTMapper
 }
 
 func (r *restClientGetter) ToRESTConfig() (*rest.Config, error) {
@@ -368,20 +437,11 @@ func (r *restClientGetter) ToRESTConfig() (*rest.Config, error) {
 }
 
 func (r *restClientGetter) ToDiscoveryClient() (discovery.CachedDiscoveryInterface, error) {
-	discoveryClient, err := discovery.NewDiscoveryClientForConfig(r.config)
-	if err != nil {
-		return nil, err
-	}
-	return memory.NewMemCacheClient(discoveryClient), nil
+	return r.discovery, nil
 }
 
 func (r *restClientGetter) ToRESTMapper() (meta.RESTMapper, error) {
-	discoveryClient, err := r.ToDiscoveryClient()
-	if err != nil {
-		return nil, err
-	}
-	mapper := restmapper.NewDeferredDiscoveryRESTMapper(discoveryClient)
-	return mapper, nil
+	return r.mapper, nil
 }
 
 func (r *restClientGetter) ToRawKubeConfigLoader() clientcmd.ClientConfig {
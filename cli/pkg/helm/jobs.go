@@ -0,0 +1,239 @@
+// Copyright 2025 Laszlo Consulting Kft.
+// SPDX-License-Identifier: Apache-2.0
+
+package helm
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// JobStatus is the state of a Helm action running in the background
+type JobStatus string
+
+const (
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	JobFailed    JobStatus = "failed"
+)
+
+// jobRetention is how long finished jobs can still be looked up
+const jobRetention = time.Hour
+
+// maxJobEvents is how many events of a job are kept for late subscribers.
+// Test runs stream pod logs, so the oldest progress is dropped beyond it.
+const maxJobEvents = 1000
+
+// jobSubscriberBuffer is how many events a subscriber can fall behind before it misses some
+const jobSubscriberBuffer = 100
+
+// Job is a Helm action, like a rollback or a test run, that runs in the background because it
+// can take longer than browsers and proxies wait for an HTTP response
+type Job struct {
+	ID         string     `json:"id"`
+	Action     string     `json:"action"`
+	Namespace  string     `json:"namespace"`
+	Release    string     `json:"release"`
+	Revision   int        `json:"revision,omitempty"`
+	Status     JobStatus  `json:"status"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  time.Time  `json:"startedAt"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+}
+
+// Job event types
+const (
+	JobEventStatus   = "status"   // the job started or finished, Job is set
+	JobEventRelease  = "release"  // the release changed status, e.g. to pending-rollback
+	JobEventResource = "resource" // a resource of the release changed readiness
+	JobEventHook     = "hook"     // a hook, like a test, changed phase or finished, Hook is set
+	JobEventLog      = "log"      // a log line of a hook's pod in Message, Hook is set
+)
+
+// JobEvent is a step of a job's progress
+type JobEvent struct {
+	Type          string          `json:"type"`
+	Time          time.Time       `json:"time"`
+	Job           *Job            `json:"job,omitempty"`
+	ReleaseStatus string          `json:"releaseStatus,omitempty"`
+	Revision      int             `json:"revision,omitempty"`
+	Resource      *ResourceStatus `json:"resource,omitempty"`
+	Hook          *HookStatus     `json:"hook,omitempty"`
+	Message       string          `json:"message,omitempty"`
+}
+
+// Jobs keeps the background Helm jobs of a context and their progress.
+// Finished jobs are forgotten after an hour. It is safe for concurrent use.
+type Jobs struct {
+	mu   sync.Mutex
+	jobs map[string]*jobState
+}
+
+type jobState struct {
+	job         Job
+	events      []JobEvent
+	subscribers map[chan JobEvent]struct{}
+}
+
+// NewJobs creates an empty job registry
+func NewJobs() *Jobs {
+	return &Jobs{jobs: make(map[string]*jobState)}
+}
+
+// Start runs fn in the background as a new job and returns the job as started.
+// fn reports progress through report; its error, if any, fails the job.
+// onFinish, if set, is called with the finished job.
+func (j *Jobs) Start(job Job, fn func(report func(JobEvent)) error, onFinish func(Job)) (Job, error) {
+	id, err := newJobID()
+	if err != nil {
+		return Job{}, err
+	}
+	job.ID = id
+	job.Status = JobRunning
+	job.StartedAt = time.Now()
+
+	j.mu.Lock()
+	j.pruneLocked(job.StartedAt)
+	j.jobs[id] = &jobState{job: job, subscribers: make(map[chan JobEvent]struct{})}
+	j.mu.Unlock()
+
+	j.publish(id, JobEvent{Type: JobEventStatus, Job: &job})
+
+	go func() {
+		err := fn(func(ev JobEvent) { j.publish(id, ev) })
+		finished := j.finish(id, err)
+		if onFinish != nil {
+			onFinish(finished)
+		}
+	}()
+	return job, nil
+}
+
+// Get returns a job by ID
+func (j *Jobs) Get(id string) (Job, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	state, ok := j.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return state.job, true
+}
+
+// Subscribe returns the events a job had so far and a channel of its further events,
+// which is closed when the job finishes. A subscriber that falls behind misses progress events,
+// but always gets the final status event before the channel is closed.
+// The returned function stops the subscription.
+func (j *Jobs) Subscribe(id string) ([]JobEvent, <-chan JobEvent, func(), bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	state, ok := j.jobs[id]
+	if !ok {
+		return nil, nil, nil, false
+	}
+	past := append([]JobEvent(nil), state.events...)
+	ch := make(chan JobEvent, jobSubscriberBuffer)
+	if state.job.FinishedAt != nil {
+		close(ch)
+		return past, ch, func() {}, true
+	}
+	state.subscribers[ch] = struct{}{}
+	cancel := func() {
+		j.mu.Lock()
+		defer j.mu.Unlock()
+		if _, ok := state.subscribers[ch]; ok {
+			delete(state.subscribers, ch)
+			close(ch)
+		}
+	}
+	return past, ch, cancel, true
+}
+
+// publish records an event and hands it to the subscribers
+func (j *Jobs) publish(id string, ev JobEvent) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if state, ok := j.jobs[id]; ok {
+		state.publishLocked(ev)
+	}
+}
+
+// finish records the outcome of a job, ends its subscriptions and returns the finished job
+func (j *Jobs) finish(id string, err error) Job {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	state, ok := j.jobs[id]
+	if !ok {
+		return Job{}
+	}
+	now := time.Now()
+	state.job.FinishedAt = &now
+	state.job.Status = JobSucceeded
+	if err != nil {
+		state.job.Status = JobFailed
+		state.job.Error = err.Error()
+	}
+	job := state.job
+	final := JobEvent{Type: JobEventStatus, Time: now, Job: &job, Message: job.Error}
+	state.recordLocked(final)
+
+	for ch := range state.subscribers {
+		select {
+		case ch <- final:
+		default:
+			// The subscriber is behind: it misses its oldest pending event rather than the outcome.
+			// Only publishers under the lock send, so there is room after taking one out.
+			select {
+			case <-ch:
+			default:
+			}
+			ch <- final
+		}
+		close(ch)
+	}
+	state.subscribers = map[chan JobEvent]struct{}{}
+	return job
+}
+
+// publishLocked records an event and hands it to the subscribers; slow subscribers miss events
+func (s *jobState) publishLocked(ev JobEvent) {
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	s.recordLocked(ev)
+	for ch := range s.subscribers {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// recordLocked adds an event to the job's history, dropping the oldest progress
+// but keeping the start event once the history is full
+func (s *jobState) recordLocked(ev JobEvent) {
+	s.events = append(s.events, ev)
+	if len(s.events) > maxJobEvents {
+		s.events = append(s.events[:1], s.events[len(s.events)-maxJobEvents+1:]...)
+	}
+}
+
+// pruneLocked forgets jobs that finished more than jobRetention ago
+func (j *Jobs) pruneLocked(now time.Time) {
+	for id, state := range j.jobs {
+		if state.job.FinishedAt != nil && now.Sub(*state.job.FinishedAt) > jobRetention {
+			delete(j.jobs, id)
+		}
+	}
+}
+
+func newJobID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate job ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,129 @@
+// Copyright 2025 Laszlo Consulting Kft.
+// SPDX-License-Identifier: Apache-2.0
+
+package helm
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// runJob starts a job that reports n log events and waits until it finished
+func runJob(t *testing.T, jobs *Jobs, n int, fnErr error, before func(id string)) Job {
+	t.Helper()
+	start := make(chan struct{})
+	done := make(chan Job, 1)
+	job, err := jobs.Start(Job{Action: "test", Namespace: "default", Release: "web"}, func(report func(JobEvent)) error {
+		<-start
+		for i := 0; i < n; i++ {
+			report(JobEvent{Type: JobEventLog, Message: fmt.Sprint(i)})
+		}
+		return fnErr
+	}, func(finished Job) { done <- finished })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if before != nil {
+		before(job.ID)
+	}
+	close(start)
+
+	select {
+	case finished := <-done:
+		return finished
+	case <-time.After(5 * time.Second):
+		t.Fatal("job didn't finish")
+		return Job{}
+	}
+}
+
+// readAll reads a subscription until its channel is closed
+func readAll(t *testing.T, events <-chan JobEvent) []JobEvent {
+	t.Helper()
+	var out []JobEvent
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				return out
+			}
+			out = append(out, ev)
+		case <-time.After(5 * time.Second):
+			t.Fatal("subscription wasn't closed")
+		}
+	}
+}
+
+func TestJobsOutcome(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus JobStatus
+		wantError  string
+	}{
+		{name: "succeeded", wantStatus: JobSucceeded},
+		{name: "failed", err: errors.New("timed out waiting for the condition"), wantStatus: JobFailed, wantError: "timed out waiting for the condition"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jobs := NewJobs()
+			finished := runJob(t, jobs, 3, tt.err, nil)
+			if finished.Status != tt.wantStatus || finished.Error != tt.wantError || finished.FinishedAt == nil {
+				t.Fatalf("finished job = %+v, want status %s and error %q", finished, tt.wantStatus, tt.wantError)
+			}
+			if job, ok := jobs.Get(finished.ID); !ok || job.Status != tt.wantStatus {
+				t.Errorf("Get() = %+v, %v, want the finished job", job, ok)
+			}
+
+			past, events, _, ok := jobs.Subscribe(finished.ID)
+			if !ok {
+				t.Fatal("Subscribe() didn't find the job")
+			}
+			if len(past) != 5 || past[0].Job == nil || past[0].Job.Status != JobRunning || past[4].Job == nil || past[4].Job.Status != tt.wantStatus {
+				t.Errorf("past events = %+v, want the start, 3 logs and the outcome", past)
+			}
+			if rest := readAll(t, events); len(rest) != 0 {
+				t.Errorf("subscription of a finished job got %d events, want none", len(rest))
+			}
+		})
+	}
+}
+
+func TestJobsSlowSubscriberGetsOutcome(t *testing.T) {
+	jobs := NewJobs()
+	var events <-chan JobEvent
+	finished := runJob(t, jobs, 2*jobSubscriberBuffer, nil, func(id string) {
+		_, events, _, _ = jobs.Subscribe(id)
+	})
+
+	got := readAll(t, events)
+	if len(got) != jobSubscriberBuffer {
+		t.Fatalf("got %d events, want a full buffer of %d", len(got), jobSubscriberBuffer)
+	}
+	last := got[len(got)-1]
+	if last.Type != JobEventStatus || last.Job == nil || last.Job.Status != JobSucceeded || last.Job.ID != finished.ID {
+		t.Errorf("last event = %+v, want the outcome of the job", last)
+	}
+}
+
+func TestJobsHistoryIsCapped(t *testing.T) {
+	jobs := NewJobs()
+	finished := runJob(t, jobs, 2*maxJobEvents, nil, nil)
+
+	past, _, _, _ := jobs.Subscribe(finished.ID)
+	if len(past) != maxJobEvents {
+		t.Fatalf("got %d past events, want %d", len(past), maxJobEvents)
+	}
+	if first := past[0]; first.Job == nil || first.Job.Status != JobRunning {
+		t.Errorf("first event = %+v, want the start of the job", first)
+	}
+	if p := past[1]; p.Type != JobEventLog || p.Message != fmt.Sprint(maxJobEvents+2) {
+		t.Errorf("second event = %+v, want the oldest log kept", p)
+	}
+	if last := past[len(past)-1]; last.Job == nil || last.Job.Status != JobSucceeded {
+		t.Errorf("last event = %+v, want the outcome of the job", last)
+	}
+}
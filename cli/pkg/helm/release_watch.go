@@ -0,0 +1,269 @@
+// Copyright 2025 Laszlo Consulting Kft.
+// SPDX-License-Identifier: Apache-2.0
+
+package helm
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"sort"
+	"strconv"
+
+	"github.com/gimlet-io/capacitor/pkg/kubernetes"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+// releaseStorageSelector selects the Secrets and ConfigMaps the Helm storage drivers keep releases in
+const releaseStorageSelector = "owner=helm"
+
+// ReleaseStoragePath returns the API path of the objects a storage driver keeps releases in,
+// in a namespace or in every namespace if namespace is empty or "all-namespaces".
+// It returns false for the sql driver, whose releases can't be watched.
+func ReleaseStoragePath(driver, namespace string) (string, bool) {
+	var resource string
+	switch driver {
+	case DriverSecret:
+		resource = "secrets"
+	case DriverConfigMap:
+		resource = "configmaps"
+	default:
+		return "", false
+	}
+	query := "?labelSelector=" + url.QueryEscape(releaseStorageSelector)
+	if namespace == "" || namespace == "all-namespaces" {
+		return "/api/v1/" + resource + query, true
+	}
+	return "/api/v1/namespaces/" + url.PathEscape(namespace) + "/" + resource + query, true
+}
+
+// ReleaseEvent is a change of a release, derived from its storage objects
+type ReleaseEvent struct {
+	Type    string // ADDED, MODIFIED or DELETED
+	Release *Release
+}
+
+// ReleaseTracker turns watch events of Helm release Secrets or ConfigMaps into release events.
+// Every revision of a release is an object; the tracker keeps the revisions it has seen and
+// only decodes a release when the object of its latest revision changed, like `helm list` does.
+// It is not safe for concurrent use.
+type ReleaseTracker struct {
+	driver   string
+	releases map[string]*trackedRelease // keyed by namespace/release name
+}
+
+type trackedRelease struct {
+	revisions map[string]*releaseObject // keyed by object name
+	sent      *Release
+	sentFrom  string // name and resourceVersion of the object sent was decoded from
+}
+
+type releaseObject struct {
+	name            string
+	namespace       string
+	release         string
+	version         int
+	resourceVersion string
+	data            []byte
+}
+
+// NewReleaseTracker creates an empty ReleaseTracker for the objects of a storage driver,
+// DriverSecret or DriverConfigMap
+func NewReleaseTracker(driver string) *ReleaseTracker {
+	return &ReleaseTracker{driver: driver, releases: make(map[string]*trackedRelease)}
+}
+
+// Apply processes a batch of Secret or ConfigMap watch events and returns the resulting release events.
+// Passing events in batches, e.g. the initial list at once, means a release with
+// many revisions is decoded once instead of once per revision.
+func (t *ReleaseTracker) Apply(events []*kubernetes.WatchEvent) []ReleaseEvent {
+	touched := map[string]bool{}
+
+	for _, event := range events {
+		switch event.Type {
+		case kubernetes.SyncedEventType:
+			for key := range t.prune(event.Object) {
+				touched[key] = true
+			}
+		case "ADDED", "MODIFIED", "DELETED":
+			obj, ok := parseReleaseObject(event.Object, t.driver)
+			if !ok {
+				continue
+			}
+			key := obj.namespace + "/" + obj.release
+			rel, exists := t.releases[key]
+			if !exists {
+				if event.Type == "DELETED" {
+					continue
+				}
+				rel = &trackedRelease{revisions: make(map[string]*releaseObject)}
+				t.releases[key] = rel
+			}
+			if event.Type == "DELETED" {
+				delete(rel.revisions, obj.name)
+			} else {
+				rel.revisions[obj.name] = obj
+			}
+			touched[key] = true
+		}
+	}
+
+	keys := make([]string, 0, len(touched))
+	for key := range touched {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var out []ReleaseEvent
+	for _, key := range keys {
+		if ev, ok := t.update(key); ok {
+			out = append(out, ev)
+		}
+	}
+	return out
+}
+
+// update compares the latest revision of a release with what was last sent
+func (t *ReleaseTracker) update(key string) (ReleaseEvent, bool) {
+	rel := t.releases[key]
+	if rel == nil {
+		return ReleaseEvent{}, false
+	}
+
+	var latest *releaseObject
+	for _, obj := range rel.revisions {
+		if latest == nil || obj.version > latest.version {
+			latest = obj
+		}
+	}
+
+	if latest == nil {
+		delete(t.releases, key)
+		if rel.sent == nil {
+			return ReleaseEvent{}, false
+		}
+		return ReleaseEvent{Type: "DELETED", Release: rel.sent}, true
+	}
+
+	from := latest.name + "@" + latest.resourceVersion
+	if from == rel.sentFrom {
+		return ReleaseEvent{}, false
+	}
+
+	decoded, err := decodeRelease(latest.data)
+	if err != nil {
+		// Not a release we can read, keep showing the last one we could
+		log.Printf("Failed to decode Helm release %s from %s: %v", key, latest.name, err)
+		return ReleaseEvent{}, false
+	}
+
+	eventType := "MODIFIED"
+	if rel.sent == nil {
+		eventType = "ADDED"
+	}
+	rel.sent = convertRelease(decoded)
+	rel.sentFrom = from
+	return ReleaseEvent{Type: eventType, Release: rel.sent}, true
+}
+
+// prune drops the revisions missing from the re-list described by a SYNCED event
+// and returns the releases it touched
+func (t *ReleaseTracker) prune(raw json.RawMessage) map[string]bool {
+	var synced kubernetes.WatchSync
+	if err := json.Unmarshal(raw, &synced); err != nil {
+		return nil
+	}
+	listed := make(map[string]struct{}, len(synced.Objects))
+	for _, name := range synced.Objects {
+		listed[name] = struct{}{}
+	}
+
+	touched := map[string]bool{}
+	for key, rel := range t.releases {
+		for name, obj := range rel.revisions {
+			if _, ok := listed[obj.namespace+"/"+name]; !ok {
+				delete(rel.revisions, name)
+				touched[key] = true
+			}
+		}
+	}
+	return touched
+}
+
+// parseReleaseObject reads the labels and the payload of a Helm release Secret or ConfigMap,
+// without decoding the release. Secret data is base64 encoded by the API on top of what Helm stored,
+// ConfigMap data is what Helm stored.
+func parseReleaseObject(raw json.RawMessage, driver string) (*releaseObject, bool) {
+	var obj struct {
+		Metadata struct {
+			Name            string            `json:"name"`
+			Namespace       string            `json:"namespace"`
+			ResourceVersion string            `json:"resourceVersion"`
+			Labels          map[string]string `json:"labels"`
+		} `json:"metadata"`
+		Data map[string]string `json:"data"`
+	}
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return nil, false
+	}
+	data := []byte(obj.Data["release"])
+	if driver == DriverSecret {
+		decoded, err := base64.StdEncoding.DecodeString(obj.Data["release"])
+		if err != nil {
+			return nil, false
+		}
+		data = decoded
+	}
+	labels := obj.Metadata.Labels
+	if labels["owner"] != "helm" || labels["name"] == "" {
+		return nil, false
+	}
+	version, err := strconv.Atoi(labels["version"])
+	if err != nil {
+		return nil, false
+	}
+	return &releaseObject{
+		name:            obj.Metadata.Name,
+		namespace:       obj.Metadata.Namespace,
+		release:         labels["name"],
+		version:         version,
+		resourceVersion: obj.Metadata.ResourceVersion,
+		data:            data,
+	}, true
+}
+
+// gzipMagic is the header of gzip data, Helm compresses releases before encoding them
+var gzipMagic = []byte{0x1f, 0x8b, 0x08}
+
+// decodeRelease decodes the payload of a release Secret or ConfigMap the way Helm's storage driver does:
+// base64, optionally gzipped, JSON
+func decodeRelease(data []byte) (*release.Release, error) {
+	b := make([]byte, base64.StdEncoding.DecodedLen(len(data)))
+	n, err := base64.StdEncoding.Decode(b, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode release: %w", err)
+	}
+	b = b[:n]
+
+	if bytes.HasPrefix(b, gzipMagic) {
+		r, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress release: %w", err)
+		}
+		defer r.Close()
+		if b, err = io.ReadAll(r); err != nil {
+			return nil, fmt.Errorf("failed to decompress release: %w", err)
+		}
+	}
+
+	var rel release.Release
+	if err := json.Unmarshal(b, &rel); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal release: %w", err)
+	}
+	return &rel, nil
+}
@@ -0,0 +1,162 @@
+// Copyright 2025 Laszlo Consulting Kft.
+// SPDX-License-Identifier: Apache-2.0
+
+package helm
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/gimlet-io/capacitor/pkg/kubernetes"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+// releaseStorageEvent builds a watch event of the object a storage driver keeps a release revision in,
+// encoded the way Helm stores it
+func releaseStorageEvent(t *testing.T, typ, driver, name string, version int, resourceVersion string) *kubernetes.WatchEvent {
+	t.Helper()
+	raw, err := json.Marshal(&release.Release{
+		Name:      name,
+		Namespace: "default",
+		Version:   version,
+		Info:      &release.Info{Status: release.StatusDeployed},
+		Chart:     &chart.Chart{Metadata: &chart.Metadata{Name: "web", Version: "1.0.0"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Write(raw)
+	w.Close()
+	data := base64.StdEncoding.EncodeToString(buf.Bytes())
+	if driver == DriverSecret {
+		data = base64.StdEncoding.EncodeToString([]byte(data))
+	}
+
+	obj, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":            fmt.Sprintf("sh.helm.release.v1.%s.v%d", name, version),
+			"namespace":       "default",
+			"resourceVersion": resourceVersion,
+			"labels":          map[string]string{"owner": "helm", "name": name, "version": fmt.Sprint(version)},
+		},
+		"data": map[string]string{"release": data},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &kubernetes.WatchEvent{Type: typ, Object: obj}
+}
+
+func syncedEvent(objects ...string) *kubernetes.WatchEvent {
+	obj, _ := json.Marshal(kubernetes.WatchSync{Objects: objects})
+	return &kubernetes.WatchEvent{Type: kubernetes.SyncedEventType, Object: obj}
+}
+
+// formatReleaseEvents formats release events as "TYPE name@revision"
+func formatReleaseEvents(events []ReleaseEvent) []string {
+	var out []string
+	for _, ev := range events {
+		out = append(out, fmt.Sprintf("%s %s@%d", ev.Type, ev.Release.Name, ev.Release.Revision))
+	}
+	return out
+}
+
+func TestReleaseTracker(t *testing.T) {
+	for _, driver := range []string{DriverSecret, DriverConfigMap} {
+		t.Run(driver, func(t *testing.T) {
+			tracker := NewReleaseTracker(driver)
+			steps := []struct {
+				name   string
+				events []*kubernetes.WatchEvent
+				want   []string
+			}{
+				{
+					name: "initial list is decoded once per release",
+					events: []*kubernetes.WatchEvent{
+						releaseStorageEvent(t, "ADDED", driver, "web", 1, "10"),
+						releaseStorageEvent(t, "ADDED", driver, "web", 2, "11"),
+						releaseStorageEvent(t, "ADDED", driver, "db", 1, "12"),
+					},
+					want: []string{"ADDED db@1", "ADDED web@2"},
+				},
+				{
+					name:   "new revision",
+					events: []*kubernetes.WatchEvent{releaseStorageEvent(t, "ADDED", driver, "web", 3, "13")},
+					want:   []string{"MODIFIED web@3"},
+				},
+				{
+					name:   "older revision changed",
+					events: []*kubernetes.WatchEvent{releaseStorageEvent(t, "MODIFIED", driver, "web", 1, "14")},
+				},
+				{
+					name:   "latest revision resent unchanged",
+					events: []*kubernetes.WatchEvent{releaseStorageEvent(t, "MODIFIED", driver, "web", 3, "13")},
+				},
+				{
+					name:   "latest revision deleted",
+					events: []*kubernetes.WatchEvent{releaseStorageEvent(t, "DELETED", driver, "web", 3, "15")},
+					want:   []string{"MODIFIED web@2"},
+				},
+				{
+					name:   "revision of an unknown release deleted",
+					events: []*kubernetes.WatchEvent{releaseStorageEvent(t, "DELETED", driver, "cache", 1, "16")},
+				},
+				{
+					name: "object that isn't a release",
+					events: []*kubernetes.WatchEvent{{
+						Type:   "ADDED",
+						Object: json.RawMessage(`{"metadata":{"name":"token","namespace":"default","labels":{"owner":"me"}},"data":{}}`),
+					}},
+				},
+				{
+					name:   "re-list without a release",
+					events: []*kubernetes.WatchEvent{syncedEvent("default/sh.helm.release.v1.web.v1", "default/sh.helm.release.v1.web.v2")},
+					want:   []string{"DELETED db@1"},
+				},
+				{
+					name: "all revisions deleted",
+					events: []*kubernetes.WatchEvent{
+						releaseStorageEvent(t, "DELETED", driver, "web", 1, "17"),
+						releaseStorageEvent(t, "DELETED", driver, "web", 2, "18"),
+					},
+					want: []string{"DELETED web@2"},
+				},
+			}
+
+			for _, step := range steps {
+				got := formatReleaseEvents(tracker.Apply(step.events))
+				if fmt.Sprint(got) != fmt.Sprint(step.want) {
+					t.Fatalf("%s: got %v, want %v", step.name, got, step.want)
+				}
+			}
+		})
+	}
+}
+
+func TestReleaseStoragePath(t *testing.T) {
+	tests := []struct {
+		driver    string
+		namespace string
+		want      string
+		ok        bool
+	}{
+		{driver: DriverSecret, namespace: "default", want: "/api/v1/namespaces/default/secrets?labelSelector=owner%3Dhelm", ok: true},
+		{driver: DriverConfigMap, namespace: "all-namespaces", want: "/api/v1/configmaps?labelSelector=owner%3Dhelm", ok: true},
+		{driver: DriverSecret, want: "/api/v1/secrets?labelSelector=owner%3Dhelm", ok: true},
+		{driver: DriverSQL, namespace: "default"},
+	}
+
+	for _, tt := range tests {
+		got, ok := ReleaseStoragePath(tt.driver, tt.namespace)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ReleaseStoragePath(%q, %q) = %q, %v, want %q, %v", tt.driver, tt.namespace, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2025 Laszlo Consulting Kft.
+// SPDX-License-Identifier: Apache-2.0
+
+package helm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/storage/driver"
+)
+
+// RenderedRelease is a chart rendered the way a release would be installed or upgraded to
+type RenderedRelease struct {
+	Manifest        string // the rendered manifest, without hooks
+	CurrentManifest string // the manifest of the current revision, empty if the release isn't installed
+	Revision        int    // the current revision, 0 if the release isn't installed
+}
+
+// RenderRelease renders the chart in chartPath, a chart directory or a directory holding one, as release name
+// with values. The release is kept in storageNamespace and installs its objects into targetNamespace.
+// It is a server-side dry-run: an upgrade if the release exists, an install if it doesn't.
+func (c *Client) RenderRelease(ctx context.Context, chartPath, name, storageNamespace, targetNamespace string, values map[string]interface{}) (*RenderedRelease, error) {
+	ch, err := loadChart(chartPath)
+	if err != nil {
+		return nil, err
+	}
+
+	actionConfig, err := c.actionConfigFor(storageNamespace)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := actionConfig.Releases.Last(name)
+	switch {
+	case errors.Is(err, driver.ErrReleaseNotFound):
+		client := action.NewInstall(actionConfig)
+		client.ReleaseName = name
+		client.Namespace = targetNamespace
+		client.DryRun = true
+		client.DryRunOption = "server"
+		rendered, err := client.RunWithContext(ctx, ch, values)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render release %s: %w", name, err)
+		}
+		return &RenderedRelease{Manifest: rendered.Manifest}, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to get release %s: %w", name, err)
+	}
+
+	client := action.NewUpgrade(actionConfig)
+	client.Namespace = targetNamespace
+	client.DryRun = true
+	client.DryRunOption = "server"
+	client.ResetValues = true // values are the complete values of the release
+	rendered, err := client.RunWithContext(ctx, name, ch, values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render release %s: %w", name, err)
+	}
+	return &RenderedRelease{
+		Manifest:        rendered.Manifest,
+		CurrentManifest: current.Manifest,
+		Revision:        current.Version,
+	}, nil
+}
+
+// loadChart loads a chart from its directory, or from the only chart directory in path,
+// which is how packaged charts extract
+func loadChart(path string) (*chart.Chart, error) {
+	root := path
+	if _, err := os.Stat(filepath.Join(path, chartutil.ChartfileName)); err != nil {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read chart directory: %w", err)
+		}
+		root = ""
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				continue
+			}
+			if _, err := os.Stat(filepath.Join(path, entry.Name(), chartutil.ChartfileName)); err == nil {
+				if root != "" {
+					return nil, fmt.Errorf("more than one chart found in %s", path)
+				}
+				root = filepath.Join(path, entry.Name())
+			}
+		}
+		if root == "" {
+			return nil, fmt.Errorf("no %s found in %s", chartutil.ChartfileName, path)
+		}
+	}
+
+	ch, err := loader.Load(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chart: %w", err)
+	}
+	return ch, nil
+}
@@ -0,0 +1,270 @@
+// Copyright 2025 Laszlo Consulting Kft.
+// SPDX-License-Identifier: Apache-2.0
+
+package helm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fluxcd/cli-utils/pkg/kstatus/status"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+// ResourceStatus is the readiness of a live object of a release, computed with kstatus
+type ResourceStatus struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+	Status     string `json:"status"` // kstatus: Current, InProgress, Failed, Terminating, NotFound or Unknown
+	Message    string `json:"message,omitempty"`
+}
+
+// manifestObjects parses a release manifest into objects, in the order Helm would list them.
+// Objects without a namespace get the release namespace if their kind is namespaced.
+func (c *Client) manifestObjects(manifest, namespace string) ([]*unstructured.Unstructured, error) {
+	docs := releaseutil.SplitManifests(manifest)
+	keys := make([]string, 0, len(docs))
+	for key := range docs {
+		keys = append(keys, key)
+	}
+	sort.Sort(releaseutil.BySplitManifestsOrder(keys))
+
+	var objects []*unstructured.Unstructured
+	for _, key := range keys {
+		obj := &unstructured.Unstructured{}
+		if err := yaml.Unmarshal([]byte(docs[key]), &obj.Object); err != nil {
+			return nil, fmt.Errorf("failed to parse release manifest: %w", err)
+		}
+		if obj.Object == nil || obj.GetKind() == "" {
+			continue
+		}
+		if obj.GetNamespace() == "" && c.namespaced(obj) {
+			obj.SetNamespace(namespace)
+		}
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
+
+// namespaced reports whether the kind of an object is namespaced. Unknown kinds are treated as namespaced.
+func (c *Client) namespaced(obj *unstructured.Unstructured) bool {
+	gvk := obj.GroupVersionKind()
+	mapping, err := c.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return true
+	}
+	return mapping.Scope.Name() == meta.RESTScopeNameNamespace
+}
+
+// ReleaseResource is an object of a release manifest resolved against the live cluster
+type ReleaseResource struct {
+	ResourceStatus
+	ID         string `json:"id"`                 // Flux inventory ID: namespace_name_group_kind
+	Resource   string `json:"resource,omitempty"` // the API resource from discovery, e.g. deployments
+	Namespaced bool   `json:"namespaced"`
+	Exists     bool   `json:"exists"`
+}
+
+// ReleaseResources are the objects a release revision created, like the inventory of a Flux Kustomization
+type ReleaseResources struct {
+	Name      string            `json:"name"`
+	Namespace string            `json:"namespace"`
+	Revision  int               `json:"revision"`
+	Resources []ReleaseResource `json:"resources"`
+	Inventory InventoryEntries  `json:"inventory"` // the resources in the shape of status.inventory of a Kustomization
+}
+
+// InventoryEntries mirrors the inventory of a Flux Kustomization
+type InventoryEntries struct {
+	Entries []InventoryEntry `json:"entries"`
+}
+
+// InventoryEntry mirrors an inventory entry of a Flux Kustomization
+type InventoryEntry struct {
+	ID      string `json:"id"`
+	Version string `json:"v"`
+}
+
+// resourceLookupConcurrency caps the parallel GETs of live objects
+const resourceLookupConcurrency = 8
+
+// GetResources parses the manifest of a release revision into objects and looks up each in the cluster,
+// with its kstatus health. Revision 0 means the latest revision.
+func (c *Client) GetResources(ctx context.Context, name, namespace string, revision int) (*ReleaseResources, error) {
+	rel, err := c.releaseRevision(name, namespace, revision)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := c.manifestObjects(rel.Manifest, rel.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := c.liveResources(ctx, objects)
+
+	result := &ReleaseResources{
+		Name:      rel.Name,
+		Namespace: rel.Namespace,
+		Revision:  rel.Version,
+		Resources: resources,
+		Inventory: InventoryEntries{Entries: make([]InventoryEntry, 0, len(resources))},
+	}
+	for i, res := range resources {
+		result.Inventory.Entries = append(result.Inventory.Entries, InventoryEntry{
+			ID:      res.ID,
+			Version: objects[i].GroupVersionKind().Version,
+		})
+	}
+	return result, nil
+}
+
+// liveResources looks up manifest objects in the cluster in parallel, in the order of objects
+func (c *Client) liveResources(ctx context.Context, objects []*unstructured.Unstructured) []ReleaseResource {
+	resources := make([]ReleaseResource, len(objects))
+	sem := make(chan struct{}, resourceLookupConcurrency)
+	var wg sync.WaitGroup
+	for i, obj := range objects {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, obj *unstructured.Unstructured) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			resources[i] = c.liveResource(ctx, obj)
+		}(i, obj)
+	}
+	wg.Wait()
+	return resources
+}
+
+// resourceStatus looks up the live version of a manifest object and computes its readiness
+func (c *Client) resourceStatus(ctx context.Context, obj *unstructured.Unstructured) ResourceStatus {
+	return c.liveResource(ctx, obj).ResourceStatus
+}
+
+// liveResource resolves a manifest object through discovery, gets its live version and computes its readiness
+func (c *Client) liveResource(ctx context.Context, obj *unstructured.Unstructured) ReleaseResource {
+	gvk := obj.GroupVersionKind()
+	res := ReleaseResource{
+		ResourceStatus: ResourceStatus{
+			APIVersion: obj.GetAPIVersion(),
+			Kind:       obj.GetKind(),
+			Namespace:  obj.GetNamespace(),
+			Name:       obj.GetName(),
+		},
+		ID: strings.Join([]string{obj.GetNamespace(), obj.GetName(), gvk.Group, gvk.Kind}, "_"),
+	}
+
+	mapping, err := c.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		res.Status, res.Message = status.UnknownStatus.String(), err.Error()
+		return res
+	}
+	res.Resource = mapping.Resource.Resource
+	res.Namespaced = mapping.Scope.Name() == meta.RESTScopeNameNamespace
+
+	live, err := c.dynamic.Resource(mapping.Resource).Namespace(res.Namespace).Get(ctx, res.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		res.Status = status.NotFoundStatus.String()
+		return res
+	}
+	if err != nil {
+		res.Status, res.Message = status.UnknownStatus.String(), err.Error()
+		return res
+	}
+	res.Exists = true
+
+	result, err := status.Compute(live)
+	if err != nil {
+		res.Status, res.Message = status.UnknownStatus.String(), err.Error()
+		return res
+	}
+	res.Status, res.Message = result.Status.String(), result.Message
+	return res
+}
+
+// progressInterval is how often a running action reports the state of its release
+const progressInterval = time.Second
+
+// progressReporter follows a release while an action runs on it and reports what changed:
+// the status of the latest revision and the readiness of every resource.
+type progressReporter struct {
+	client       *Client
+	actionConfig *action.Configuration
+	name         string
+	objects      []*unstructured.Unstructured
+	progress     func(JobEvent)
+
+	release   string            // status@revision last reported
+	resources map[string]string // status and message last reported, keyed by resource
+}
+
+func newProgressReporter(c *Client, actionConfig *action.Configuration, name string, objects []*unstructured.Unstructured, progress func(JobEvent)) *progressReporter {
+	return &progressReporter{
+		client:       c,
+		actionConfig: actionConfig,
+		name:         name,
+		objects:      objects,
+		progress:     progress,
+		resources:    make(map[string]string),
+	}
+}
+
+// run reports every progressInterval until ctx is done
+func (r *progressReporter) run(ctx context.Context) {
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+	for {
+		r.report(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// report sends the changes since the last report
+func (r *progressReporter) report(ctx context.Context) {
+	if r.progress == nil {
+		return
+	}
+
+	if rel, err := r.actionConfig.Releases.Last(r.name); err == nil && rel.Info != nil {
+		current := fmt.Sprintf("%s@%d", rel.Info.Status, rel.Version)
+		if current != r.release {
+			r.release = current
+			r.progress(JobEvent{
+				Type:          JobEventRelease,
+				ReleaseStatus: rel.Info.Status.String(),
+				Revision:      rel.Version,
+				Message:       rel.Info.Description,
+			})
+		}
+	}
+
+	for _, obj := range r.objects {
+		if ctx.Err() != nil {
+			return
+		}
+		rs := r.client.resourceStatus(ctx, obj)
+		key := rs.Kind + "/" + rs.Namespace + "/" + rs.Name
+		current := rs.Status + ": " + rs.Message
+		if r.resources[key] == current {
+			continue
+		}
+		r.resources[key] = current
+		r.progress(JobEvent{Type: JobEventResource, Resource: &rs})
+	}
+}
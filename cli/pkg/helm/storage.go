@@ -0,0 +1,77 @@
+// Copyright 2025 Laszlo Consulting Kft.
+// SPDX-License-Identifier: Apache-2.0
+
+package helm
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/storage"
+	"helm.sh/helm/v3/pkg/storage/driver"
+)
+
+// Storage drivers Helm keeps releases with, the values of HELM_DRIVER
+const (
+	DriverSecret    = "secret"
+	DriverConfigMap = "configmap"
+	DriverSQL       = "sql"
+)
+
+// Storage selects where the releases of a cluster are kept
+type Storage struct {
+	Driver string // secret (the default), configmap or sql
+
+	// SQLConnectionString is the Postgres connection string of the sql driver,
+	// like HELM_DRIVER_SQL_CONNECTION_STRING
+	SQLConnectionString string
+}
+
+// ParseDriver normalises a storage driver name. Empty means the default, secret;
+// the plural forms Helm accepts are accepted too.
+func ParseDriver(name string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "secret", "secrets":
+		return DriverSecret, nil
+	case "configmap", "configmaps":
+		return DriverConfigMap, nil
+	case "sql":
+		return DriverSQL, nil
+	default:
+		return "", fmt.Errorf("unsupported Helm storage driver %q, use secret, configmap or sql", name)
+	}
+}
+
+// validate normalises the driver and checks that the sql driver has somewhere to connect to
+func (s Storage) validate() (Storage, error) {
+	d, err := ParseDriver(s.Driver)
+	if err != nil {
+		return s, err
+	}
+	s.Driver = d
+	if s.Driver == DriverSQL && s.SQLConnectionString == "" {
+		return s, fmt.Errorf("the sql Helm storage driver needs a connection string")
+	}
+	return s, nil
+}
+
+// initActionConfig initialises an action configuration on the storage.
+// Helm reads the sql connection string from the process environment, which can't differ per context,
+// so the sql driver is set up here instead.
+func (s Storage) initActionConfig(actionConfig *action.Configuration, getter *restClientGetter, namespace string) error {
+	if s.Driver != DriverSQL {
+		return actionConfig.Init(getter, namespace, s.Driver, log.Printf)
+	}
+
+	if err := actionConfig.Init(getter, namespace, "memory", log.Printf); err != nil {
+		return err
+	}
+	d, err := driver.NewSQL(s.SQLConnectionString, log.Printf, namespace)
+	if err != nil {
+		return fmt.Errorf("unable to instantiate SQL driver: %w", err)
+	}
+	actionConfig.Releases = storage.Init(d)
+	return nil
+}
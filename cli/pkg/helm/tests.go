@@ -0,0 +1,273 @@
+// Copyright 2025 Laszlo Consulting Kft.
+// SPDX-License-Identifier: Apache-2.0
+
+package helm
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/release"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// logDrainTimeout is how long the logs of test pods are still read after the tests finished
+const logDrainTimeout = 5 * time.Second
+
+// TestOptions are the flags of `helm test`
+type TestOptions struct {
+	Timeout time.Duration // zero means five minutes
+}
+
+// HookStatus is the state of a hook of a release, like a test
+type HookStatus struct {
+	Name        string     `json:"name"`
+	Kind        string     `json:"kind"`
+	Phase       string     `json:"phase"` // Running, Succeeded, Failed or Unknown; while a test runs, the phase of its pod
+	StartedAt   *time.Time `json:"startedAt,omitempty"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+}
+
+// TestResults are the results of the last `helm test` run of a release, as Helm records them in the release
+type TestResults struct {
+	Name     string       `json:"name"`
+	Revision int          `json:"revision"`
+	Tests    []HookStatus `json:"tests"`
+}
+
+// GetTestResults returns the test hooks of the latest revision of a release with the results of their last run
+func (c *Client) GetTestResults(ctx context.Context, name, namespace string) (*TestResults, error) {
+	actionConfig, err := c.actionConfigFor(namespace)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := actionConfig.Releases.Last(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get release %s: %w", name, err)
+	}
+
+	results := &TestResults{Name: rel.Name, Revision: rel.Version, Tests: []HookStatus{}}
+	for _, h := range testHooks(rel) {
+		results.Tests = append(results.Tests, hookResult(h))
+	}
+	return results, nil
+}
+
+// Test runs the test hooks of a release. While they run, progress receives the phase of every test pod and
+// its log lines, and when they finished, the result of every test hook.
+func (c *Client) Test(ctx context.Context, name, namespace string, opts TestOptions, progress func(JobEvent)) error {
+	log.Printf("Test called for release %s in namespace %s", name, namespace)
+
+	actionConfig, err := c.actionConfigFor(namespace)
+	if err != nil {
+		return err
+	}
+	rel, err := actionConfig.Releases.Last(name)
+	if err != nil {
+		return fmt.Errorf("failed to get release %s: %w", name, err)
+	}
+	hooks := testHooks(rel)
+	if len(hooks) == 0 {
+		return fmt.Errorf("release %s has no tests", name)
+	}
+	clientset, err := actionConfig.KubernetesClientSet()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	client := action.NewReleaseTesting(actionConfig)
+	client.Namespace = namespace
+	client.Timeout = 300 * time.Second // 5 minute timeout for tests
+	if opts.Timeout > 0 {
+		client.Timeout = opts.Timeout
+	}
+
+	watcher := &testPodWatcher{
+		clientset: clientset,
+		namespace: namespace,
+		hooks:     hooks,
+		since:     time.Now().Truncate(time.Second), // creation timestamps have second precision
+		progress:  progress,
+		phases:    make(map[string]string),
+		following: make(map[string]bool),
+	}
+	watchCtx, stopWatching := context.WithCancel(ctx)
+	logCtx, stopLogs := context.WithCancel(ctx)
+	defer stopLogs()
+	watched := make(chan struct{})
+	go func() {
+		defer close(watched)
+		watcher.run(watchCtx, logCtx)
+	}()
+
+	tested, err := client.Run(name)
+	stopWatching()
+	<-watched
+	watcher.drainLogs(stopLogs)
+
+	// Helm records the result of every test hook in the release, also when a test failed
+	if tested != nil && progress != nil {
+		for _, h := range testHooks(tested) {
+			result := hookResult(h)
+			progress(JobEvent{Type: JobEventHook, Hook: &result})
+		}
+	}
+
+	if err != nil {
+		log.Printf("Error testing Helm release: %v", err)
+		return fmt.Errorf("tests of release %s failed: %w", name, err)
+	}
+
+	log.Printf("Tests of release %s passed", name)
+	return nil
+}
+
+// testHooks returns the test hooks of a release, in the order Helm runs them
+func testHooks(rel *release.Release) []*release.Hook {
+	var hooks []*release.Hook
+	for _, h := range rel.Hooks {
+		for _, e := range h.Events {
+			if e == release.HookTest {
+				hooks = append(hooks, h)
+				break
+			}
+		}
+	}
+	sort.SliceStable(hooks, func(i, j int) bool {
+		if hooks[i].Weight != hooks[j].Weight {
+			return hooks[i].Weight < hooks[j].Weight
+		}
+		return hooks[i].Name < hooks[j].Name
+	})
+	return hooks
+}
+
+// hookResult is the result of the last run of a hook
+func hookResult(h *release.Hook) HookStatus {
+	result := HookStatus{Name: h.Name, Kind: h.Kind, Phase: h.LastRun.Phase.String()}
+	if result.Phase == "" {
+		result.Phase = release.HookPhaseUnknown.String()
+	}
+	if !h.LastRun.StartedAt.IsZero() {
+		startedAt := h.LastRun.StartedAt.Time
+		result.StartedAt = &startedAt
+	}
+	if !h.LastRun.CompletedAt.IsZero() {
+		completedAt := h.LastRun.CompletedAt.Time
+		result.CompletedAt = &completedAt
+	}
+	return result
+}
+
+// testPodWatcher follows the pods of test hooks while the tests run: it reports their phase
+// and streams their logs. Pods created before the run, by an earlier run, are ignored.
+type testPodWatcher struct {
+	clientset kubernetes.Interface
+	namespace string
+	hooks     []*release.Hook
+	since     time.Time
+	progress  func(JobEvent)
+
+	phases    map[string]string // pod phase last reported, keyed by pod name
+	following map[string]bool   // pods whose logs are streamed
+	logs      sync.WaitGroup
+}
+
+// run polls the test pods every progressInterval until ctx is done, log streams live until logCtx is done
+func (w *testPodWatcher) run(ctx, logCtx context.Context) {
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+	for {
+		w.poll(ctx, logCtx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *testPodWatcher) poll(ctx, logCtx context.Context) {
+	for _, h := range w.hooks {
+		if h.Kind != "Pod" {
+			continue
+		}
+		pod, err := w.clientset.CoreV1().Pods(w.namespace).Get(ctx, h.Name, metav1.GetOptions{})
+		if err != nil || pod.CreationTimestamp.Time.Before(w.since) {
+			continue
+		}
+
+		phase := string(pod.Status.Phase)
+		if w.phases[pod.Name] != phase {
+			w.phases[pod.Name] = phase
+			w.report(JobEvent{Type: JobEventHook, Hook: &HookStatus{Name: h.Name, Kind: h.Kind, Phase: phase}})
+		}
+
+		if !w.following[pod.Name] && pod.Status.Phase != corev1.PodPending {
+			w.following[pod.Name] = true
+			for _, container := range pod.Spec.Containers {
+				w.logs.Add(1)
+				go func(pod *corev1.Pod, container string) {
+					defer w.logs.Done()
+					w.followLogs(logCtx, pod, container)
+				}(pod, container.Name)
+			}
+		}
+	}
+}
+
+// followLogs reports the log lines of a container of a test pod until the container exits or ctx is done
+func (w *testPodWatcher) followLogs(ctx context.Context, pod *corev1.Pod, container string) {
+	hook := &HookStatus{Name: pod.Name, Kind: "Pod"}
+	prefix := ""
+	if len(pod.Spec.Containers) > 1 {
+		prefix = "[" + container + "] "
+	}
+
+	stream, err := w.clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+		Container: container,
+		Follow:    true,
+	}).Stream(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			w.report(JobEvent{Type: JobEventLog, Hook: hook, Message: prefix + fmt.Sprintf("failed to stream logs: %v", err)})
+		}
+		return
+	}
+	defer stream.Close()
+
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		w.report(JobEvent{Type: JobEventLog, Hook: hook, Message: prefix + scanner.Text()})
+	}
+}
+
+// drainLogs waits for the log streams to reach the end of the logs, for at most logDrainTimeout, then stops them
+func (w *testPodWatcher) drainLogs(stop context.CancelFunc) {
+	done := make(chan struct{})
+	go func() {
+		w.logs.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(logDrainTimeout):
+		stop()
+		<-done
+	}
+}
+
+func (w *testPodWatcher) report(ev JobEvent) {
+	if w.progress != nil {
+		w.progress(ev)
+	}
+}
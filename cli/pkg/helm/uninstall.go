@@ -0,0 +1,196 @@
+// Copyright 2025 Laszlo Consulting Kft.
+// SPDX-License-Identifier: Apache-2.0
+
+package helm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/kube"
+	"helm.sh/helm/v3/pkg/release"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// UninstallOptions are the flags of `helm uninstall`
+type UninstallOptions struct {
+	KeepHistory  bool
+	DisableHooks bool
+	Timeout      time.Duration // zero means five minutes
+}
+
+// ReleaseHook is a hook of a release
+type ReleaseHook struct {
+	Name           string   `json:"name"`
+	Kind           string   `json:"kind"`
+	Path           string   `json:"path"`  // the template the hook was rendered from
+	Event          string   `json:"event"` // pre-delete or post-delete
+	Weight         int      `json:"weight"`
+	DeletePolicies []string `json:"deletePolicies,omitempty"`
+}
+
+// UninstallPreview is what uninstalling a release would do
+type UninstallPreview struct {
+	Revision int               `json:"revision"` // the revision of the release the preview was made against
+	Token    string            `json:"token"`    // identifies the previewed release, Uninstall requires it
+	Deleted  []ReleaseResource `json:"deleted"`  // the objects of the manifest that would be deleted
+	Kept     []ReleaseResource `json:"kept"`     // the objects Helm leaves in place, annotated with helm.sh/resource-policy: keep
+	Hooks    []ReleaseHook     `json:"hooks"`    // the pre-delete and post-delete hooks, in the order they run, unless hooks are disabled
+}
+
+// PreviewUninstall lists the objects an uninstall would delete and keep, with their live status, and the hooks it would run
+func (c *Client) PreviewUninstall(ctx context.Context, name, namespace string) (*UninstallPreview, error) {
+	actionConfig, err := c.actionConfigFor(namespace)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := actionConfig.Releases.Last(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get release %s: %w", name, err)
+	}
+
+	deleted, kept, err := c.uninstallObjects(rel)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UninstallPreview{
+		Revision: rel.Version,
+		Token:    uninstallPreviewToken(rel),
+		Deleted:  c.liveResources(ctx, deleted),
+		Kept:     c.liveResources(ctx, kept),
+		Hooks:    uninstallHooks(rel),
+	}, nil
+}
+
+// Uninstall uninstalls a release and waits for its objects to be deleted. previewToken must be the token of
+// a preview of the current release, so nothing is deleted that wasn't previewed.
+// While it runs, progress receives the deletion of the objects.
+func (c *Client) Uninstall(ctx context.Context, name, namespace, previewToken string, opts UninstallOptions, progress func(JobEvent)) error {
+	log.Printf("Uninstall called for release %s in namespace %s", name, namespace)
+
+	actionConfig, err := c.actionConfigFor(namespace)
+	if err != nil {
+		return err
+	}
+	rel, err := actionConfig.Releases.Last(name)
+	if err != nil {
+		return fmt.Errorf("failed to get release %s: %w", name, err)
+	}
+	if previewToken != uninstallPreviewToken(rel) {
+		return fmt.Errorf("release %s changed since the uninstall was previewed, it is at revision %d; preview it again", name, rel.Version)
+	}
+	deleted, _, err := c.uninstallObjects(rel)
+	if err != nil {
+		return err
+	}
+
+	client := action.NewUninstall(actionConfig)
+	client.Wait = true
+	client.Timeout = 300 * time.Second // 5 minute timeout for uninstall
+	if opts.Timeout > 0 {
+		client.Timeout = opts.Timeout
+	}
+	client.KeepHistory = opts.KeepHistory
+	client.DisableHooks = opts.DisableHooks
+
+	reporter := newProgressReporter(c, actionConfig, name, deleted, progress)
+	watchCtx, stopWatching := context.WithCancel(ctx)
+	watched := make(chan struct{})
+	go func() {
+		defer close(watched)
+		reporter.run(watchCtx)
+	}()
+
+	res, err := client.Run(name)
+	stopWatching()
+	<-watched
+	reporter.report(ctx) // the final status
+
+	if err != nil {
+		log.Printf("Error uninstalling Helm release: %v", err)
+		return fmt.Errorf("failed to uninstall release %s: %w", name, err)
+	}
+	if res != nil && res.Info != "" && progress != nil {
+		// Helm lists the resources it kept here
+		progress(JobEvent{Type: JobEventRelease, ReleaseStatus: release.StatusUninstalled.String(), Message: res.Info})
+	}
+
+	log.Printf("Successfully uninstalled release %s", name)
+	return nil
+}
+
+// uninstallPreviewToken hashes what an uninstall preview shows, so a preview can't be used
+// for another release, another revision or a manifest changed in storage
+func uninstallPreviewToken(rel *release.Release) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%d\x00", rel.Namespace, rel.Name, rel.Version)
+	h.Write([]byte(rel.Manifest))
+	for _, hook := range rel.Hooks {
+		fmt.Fprintf(h, "\x00%s\x00%s", hook.Path, hook.Manifest)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// uninstallObjects splits the objects of a release into the ones an uninstall deletes and the ones
+// it keeps because of their resource policy, the same way Helm does
+func (c *Client) uninstallObjects(rel *release.Release) ([]*unstructured.Unstructured, []*unstructured.Unstructured, error) {
+	objects, err := c.manifestObjects(rel.Manifest, rel.Namespace)
+	if err != nil {
+		return nil, nil, err
+	}
+	var deleted, kept []*unstructured.Unstructured
+	for _, obj := range objects {
+		policy := strings.ToLower(strings.TrimSpace(obj.GetAnnotations()[kube.ResourcePolicyAnno]))
+		if policy == kube.KeepPolicy {
+			kept = append(kept, obj)
+			continue
+		}
+		deleted = append(deleted, obj)
+	}
+	return deleted, kept, nil
+}
+
+// uninstallHooks returns the hooks an uninstall runs, sorted by weight and name like Helm runs them
+func uninstallHooks(rel *release.Release) []ReleaseHook {
+	hooks := []ReleaseHook{}
+	for _, event := range []release.HookEvent{release.HookPreDelete, release.HookPostDelete} {
+		var matching []*release.Hook
+		for _, h := range rel.Hooks {
+			for _, e := range h.Events {
+				if e == event {
+					matching = append(matching, h)
+					break
+				}
+			}
+		}
+		sort.SliceStable(matching, func(i, j int) bool {
+			if matching[i].Weight != matching[j].Weight {
+				return matching[i].Weight < matching[j].Weight
+			}
+			return matching[i].Name < matching[j].Name
+		})
+
+		for _, h := range matching {
+			hook := ReleaseHook{
+				Name:   h.Name,
+				Kind:   h.Kind,
+				Path:   h.Path,
+				Event:  event.String(),
+				Weight: h.Weight,
+			}
+			for _, p := range h.DeletePolicies {
+				hook.DeletePolicies = append(hook.DeletePolicies, p.String())
+			}
+			hooks = append(hooks, hook)
+		}
+	}
+	return hooks
+}
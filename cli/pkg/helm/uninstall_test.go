@@ -0,0 +1,46 @@
+// Copyright 2025 Laszlo Consulting Kft.
+// SPDX-License-Identifier: Apache-2.0
+
+package helm
+
+import (
+	"testing"
+
+	"helm.sh/helm/v3/pkg/release"
+)
+
+func TestUninstallPreviewToken(t *testing.T) {
+	previewed := func() *release.Release {
+		return &release.Release{
+			Name:      "web",
+			Namespace: "default",
+			Version:   3,
+			Manifest:  webManifest,
+			Hooks:     []*release.Hook{{Name: "web-cleanup", Path: "web/templates/cleanup.yaml", Manifest: "kind: Job"}},
+		}
+	}
+	token := uninstallPreviewToken(previewed())
+	if token == "" || token != uninstallPreviewToken(previewed()) {
+		t.Fatalf("uninstallPreviewToken() = %q, want a stable token", token)
+	}
+
+	tests := []struct {
+		name   string
+		change func(rel *release.Release)
+	}{
+		{name: "new revision", change: func(rel *release.Release) { rel.Version = 4 }},
+		{name: "manifest changed in storage", change: func(rel *release.Release) { rel.Manifest += "---\nkind: ConfigMap\n" }},
+		{name: "hook changed", change: func(rel *release.Release) { rel.Hooks[0].Manifest = "kind: Pod" }},
+		{name: "another release", change: func(rel *release.Release) { rel.Name = "api" }},
+		{name: "another namespace", change: func(rel *release.Release) { rel.Namespace = "staging" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rel := previewed()
+			tt.change(rel)
+			if uninstallPreviewToken(rel) == token {
+				t.Errorf("uninstallPreviewToken() didn't change, want a stale token")
+			}
+		})
+	}
+}
@@ -0,0 +1,308 @@
+// Copyright 2025 Laszlo Consulting Kft.
+// SPDX-License-Identifier: Apache-2.0
+
+package helm
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+// UpgradeValues are the new user-supplied values of an upgrade: either a full values document
+// that replaces the values of the release, or an RFC 7386 JSON merge patch on top of them
+type UpgradeValues struct {
+	Values map[string]interface{} `json:"values,omitempty"`
+	Patch  map[string]interface{} `json:"valuesPatch,omitempty"`
+}
+
+// UpgradeOptions are the flags of `helm upgrade` that apply when the chart doesn't change
+type UpgradeOptions struct {
+	Force         bool
+	CleanupOnFail bool
+	DisableHooks  bool
+	Timeout       time.Duration // zero means five minutes
+}
+
+// ManifestDiff is the change of a single object between two rendered manifests,
+// in the shape of the Kustomization diff
+type ManifestDiff struct {
+	FileName    string `json:"fileName"`
+	ClusterYaml string `json:"clusterYaml"`
+	AppliedYaml string `json:"appliedYaml"`
+	Created     bool   `json:"created"`
+	HasChanges  bool   `json:"hasChanges"`
+	Deleted     bool   `json:"deleted"`
+}
+
+// UpgradePreview is the dry-run of an upgrade with new values
+type UpgradePreview struct {
+	Revision int                    `json:"revision"` // the revision of the release the preview was rendered against
+	Values   map[string]interface{} `json:"values"`   // the user-supplied values the upgrade would set
+	Diff     []ManifestDiff         `json:"diff"`
+}
+
+// PreviewUpgrade renders the chart of the current release with new values, without installing anything,
+// and diffs the manifest against the current release
+func (c *Client) PreviewUpgrade(ctx context.Context, name, namespace string, values UpgradeValues) (*UpgradePreview, error) {
+	actionConfig, err := c.actionConfigFor(namespace)
+	if err != nil {
+		return nil, err
+	}
+	current, vals, err := c.upgradeInputs(actionConfig, name, values)
+	if err != nil {
+		return nil, err
+	}
+
+	rendered, err := renderUpgrade(ctx, actionConfig, name, namespace, current, vals)
+	if err != nil {
+		return nil, err
+	}
+
+	diff, err := diffManifests(current.Manifest, rendered.Manifest)
+	if err != nil {
+		return nil, err
+	}
+	return &UpgradePreview{Revision: current.Version, Values: vals, Diff: diff}, nil
+}
+
+// Upgrade upgrades a release to new values using the chart stored in the release, so no chart repository
+// is needed. fromRevision must be the current revision, the one the upgrade was previewed against.
+// While it runs, progress receives the status changes of the release and the readiness of its resources.
+func (c *Client) Upgrade(ctx context.Context, name, namespace string, fromRevision int, values UpgradeValues, opts UpgradeOptions, progress func(JobEvent)) error {
+	log.Printf("Upgrade called for release %s in namespace %s from revision %d", name, namespace, fromRevision)
+
+	actionConfig, err := c.actionConfigFor(namespace)
+	if err != nil {
+		return err
+	}
+	current, vals, err := c.upgradeInputs(actionConfig, name, values)
+	if err != nil {
+		return err
+	}
+	if current.Version != fromRevision {
+		return fmt.Errorf("release %s is at revision %d, not %d the upgrade was previewed against", name, current.Version, fromRevision)
+	}
+
+	// Render once to know which resources to follow
+	rendered, err := renderUpgrade(ctx, actionConfig, name, namespace, current, vals)
+	if err != nil {
+		return err
+	}
+	objects, err := c.manifestObjects(rendered.Manifest, namespace)
+	if err != nil {
+		return err
+	}
+
+	client := action.NewUpgrade(actionConfig)
+	client.Namespace = namespace
+	client.Wait = true
+	client.Timeout = 300 * time.Second // 5 minute timeout for upgrade
+	if opts.Timeout > 0 {
+		client.Timeout = opts.Timeout
+	}
+	client.Force = opts.Force
+	client.CleanupOnFail = opts.CleanupOnFail
+	client.DisableHooks = opts.DisableHooks
+	client.Description = "Upgrade with edited values"
+
+	reporter := newProgressReporter(c, actionConfig, name, objects, progress)
+	watchCtx, stopWatching := context.WithCancel(ctx)
+	watched := make(chan struct{})
+	go func() {
+		defer close(watched)
+		reporter.run(watchCtx)
+	}()
+
+	_, err = client.RunWithContext(ctx, name, current.Chart, vals)
+	stopWatching()
+	<-watched
+	reporter.report(ctx) // the final status
+
+	if err != nil {
+		log.Printf("Error upgrading Helm release: %v", err)
+		return fmt.Errorf("failed to upgrade release %s: %w", name, err)
+	}
+
+	log.Printf("Successfully upgraded release %s", name)
+	return nil
+}
+
+// renderUpgrade runs an upgrade as a server-side dry-run, so lookup functions see the cluster like the real upgrade will
+func renderUpgrade(ctx context.Context, actionConfig *action.Configuration, name, namespace string, current *release.Release, vals map[string]interface{}) (*release.Release, error) {
+	client := action.NewUpgrade(actionConfig)
+	client.Namespace = namespace
+	client.DryRun = true
+	client.DryRunOption = "server"
+	rendered, err := client.RunWithContext(ctx, name, current.Chart, vals)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render release %s with the new values: %w", name, err)
+	}
+	return rendered, nil
+}
+
+// upgradeInputs returns the current release and the values an upgrade would set
+func (c *Client) upgradeInputs(actionConfig *action.Configuration, name string, values UpgradeValues) (*release.Release, map[string]interface{}, error) {
+	current, err := action.NewGet(actionConfig).Run(name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get release %s: %w", name, err)
+	}
+	if current.Chart == nil || current.Chart.Metadata == nil {
+		return nil, nil, fmt.Errorf("release %s has no chart stored", name)
+	}
+	if err := checkStoredChartComplete(current); err != nil {
+		return nil, nil, err
+	}
+
+	switch {
+	case values.Values != nil && values.Patch != nil:
+		return nil, nil, fmt.Errorf("set either values or valuesPatch, not both")
+	case values.Values != nil:
+		return current, values.Values, nil
+	case values.Patch != nil:
+		return current, mergeValuesPatch(current.Config, values.Patch), nil
+	default:
+		return nil, nil, fmt.Errorf("values or valuesPatch is required")
+	}
+}
+
+// checkStoredChartComplete refuses releases the chart stored with them can't render in full.
+// Helm doesn't store subcharts with a release, neither dependencies nor charts vendored under charts/,
+// so rendering without them would uninstall their resources.
+func checkStoredChartComplete(rel *release.Release) error {
+	chartName := rel.Chart.Metadata.Name
+	if len(rel.Chart.Metadata.Dependencies) > 0 {
+		return fmt.Errorf("chart %s of release %s has dependencies, which Helm doesn't keep in the release; upgrade it from its chart source instead",
+			chartName, rel.Name)
+	}
+
+	templates := make(map[string]bool, len(rel.Chart.Templates))
+	for _, t := range rel.Chart.Templates {
+		templates[path.Join(chartName, t.Name)] = true
+	}
+	sources := manifestSources(rel.Manifest)
+	for _, hook := range rel.Hooks {
+		sources = append(sources, hook.Path)
+	}
+	for _, source := range sources {
+		if strings.HasPrefix(source, chartName+"/charts/") {
+			return fmt.Errorf("release %s has objects from the subchart template %s, which Helm doesn't keep in the release; upgrade it from its chart source instead",
+				rel.Name, source)
+		}
+		if !templates[source] {
+			return fmt.Errorf("release %s has objects from the template %s, which is missing from the chart stored in the release; upgrade it from its chart source instead",
+				rel.Name, source)
+		}
+	}
+	return nil
+}
+
+// manifestSources returns the templates a rendered manifest was rendered from, as in its "# Source:" comments
+func manifestSources(manifest string) []string {
+	var sources []string
+	for _, line := range strings.Split(manifest, "\n") {
+		if source, ok := strings.CutPrefix(strings.TrimSpace(line), "# Source: "); ok {
+			sources = append(sources, strings.TrimSpace(source))
+		}
+	}
+	return sources
+}
+
+// mergeValuesPatch applies an RFC 7386 JSON merge patch to values: null removes a key,
+// objects are merged, anything else replaces. values is not modified.
+func mergeValuesPatch(values, patch map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		out[k] = v
+	}
+	for k, pv := range patch {
+		if pv == nil {
+			delete(out, k)
+			continue
+		}
+		pm, patchIsMap := pv.(map[string]interface{})
+		vm, valueIsMap := out[k].(map[string]interface{})
+		if patchIsMap && valueIsMap {
+			out[k] = mergeValuesPatch(vm, pm)
+			continue
+		}
+		if patchIsMap {
+			out[k] = mergeValuesPatch(nil, pm)
+			continue
+		}
+		out[k] = pv
+	}
+	return out
+}
+
+// diffManifests compares two rendered release manifests object by object
+func diffManifests(currentManifest, newManifest string) ([]ManifestDiff, error) {
+	current, err := manifestDocuments(currentManifest)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := manifestDocuments(newManifest)
+	if err != nil {
+		return nil, err
+	}
+
+	subjects := make([]string, 0, len(current)+len(updated))
+	for subject := range updated {
+		subjects = append(subjects, subject)
+	}
+	for subject := range current {
+		if _, ok := updated[subject]; !ok {
+			subjects = append(subjects, subject)
+		}
+	}
+	sort.Strings(subjects)
+
+	diff := make([]ManifestDiff, 0, len(subjects))
+	for _, subject := range subjects {
+		before, existed := current[subject]
+		after, exists := updated[subject]
+		switch {
+		case !existed:
+			diff = append(diff, ManifestDiff{FileName: subject, AppliedYaml: after, Created: true, HasChanges: true})
+		case !exists:
+			diff = append(diff, ManifestDiff{FileName: subject, ClusterYaml: before, Deleted: true, HasChanges: true})
+		default:
+			diff = append(diff, ManifestDiff{FileName: subject, ClusterYaml: before, AppliedYaml: after, HasChanges: before != after})
+		}
+	}
+	return diff, nil
+}
+
+// manifestDocuments splits a rendered manifest into normalised YAML documents keyed by Kind/namespace/name
+func manifestDocuments(manifest string) (map[string]string, error) {
+	docs := map[string]string{}
+	for _, doc := range releaseutil.SplitManifests(manifest) {
+		obj := &unstructured.Unstructured{}
+		if err := yaml.Unmarshal([]byte(doc), &obj.Object); err != nil {
+			return nil, fmt.Errorf("failed to parse release manifest: %w", err)
+		}
+		if obj.Object == nil || obj.GetKind() == "" {
+			continue
+		}
+		normalised, err := yaml.Marshal(obj.Object)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal object to YAML: %w", err)
+		}
+		subject := obj.GetKind() + "/" + obj.GetName()
+		if obj.GetNamespace() != "" {
+			subject = obj.GetKind() + "/" + obj.GetNamespace() + "/" + obj.GetName()
+		}
+		docs[subject] = string(normalised)
+	}
+	return docs, nil
+}
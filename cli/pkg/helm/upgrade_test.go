@@ -0,0 +1,178 @@
+// Copyright 2025 Laszlo Consulting Kft.
+// SPDX-License-Identifier: Apache-2.0
+
+package helm
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+const webManifest = `---
+# Source: web/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+---
+# Source: web/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+`
+
+// A chart with redis vendored under charts/, without a dependency in Chart.yaml
+const vendoredSubchartManifest = webManifest + `---
+# Source: web/charts/redis/templates/statefulset.yaml
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: web-redis
+`
+
+func webRelease(manifest string, hooks []*release.Hook, dependencies []*chart.Dependency) *release.Release {
+	return &release.Release{
+		Name:     "web",
+		Manifest: manifest,
+		Hooks:    hooks,
+		Chart: &chart.Chart{
+			Metadata: &chart.Metadata{Name: "web", Version: "1.0.0", Dependencies: dependencies},
+			Templates: []*chart.File{
+				{Name: "templates/service.yaml"},
+				{Name: "templates/deployment.yaml"},
+				{Name: "templates/tests/connection.yaml"},
+			},
+		},
+	}
+}
+
+func TestCheckStoredChartComplete(t *testing.T) {
+	tests := []struct {
+		name    string
+		release *release.Release
+		wantErr string
+	}{
+		{
+			name:    "all templates stored",
+			release: webRelease(webManifest, []*release.Hook{{Name: "web-test", Path: "web/templates/tests/connection.yaml"}}, nil),
+		},
+		{
+			name:    "dependencies",
+			release: webRelease(webManifest, nil, []*chart.Dependency{{Name: "redis"}}),
+			wantErr: "has dependencies",
+		},
+		{
+			name:    "vendored subchart",
+			release: webRelease(vendoredSubchartManifest, nil, nil),
+			wantErr: "subchart template web/charts/redis/templates/statefulset.yaml",
+		},
+		{
+			name:    "vendored subchart hook",
+			release: webRelease(webManifest, []*release.Hook{{Name: "redis-test", Path: "web/charts/redis/templates/tests/ping.yaml"}}, nil),
+			wantErr: "subchart template web/charts/redis/templates/tests/ping.yaml",
+		},
+		{
+			name:    "template missing from the stored chart",
+			release: webRelease(webManifest+"---\n# Source: web/templates/ingress.yaml\nkind: Ingress\n", nil, nil),
+			wantErr: "template web/templates/ingress.yaml, which is missing",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkStoredChartComplete(tt.release)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("checkStoredChartComplete() error = %v, want none", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("checkStoredChartComplete() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMergeValuesPatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		values map[string]interface{}
+		patch  map[string]interface{}
+		want   map[string]interface{}
+	}{
+		{
+			name:   "replace a scalar",
+			values: map[string]interface{}{"replicaCount": 1, "image": "nginx"},
+			patch:  map[string]interface{}{"replicaCount": 3},
+			want:   map[string]interface{}{"replicaCount": 3, "image": "nginx"},
+		},
+		{
+			name:   "merge objects",
+			values: map[string]interface{}{"image": map[string]interface{}{"repository": "nginx", "tag": "1.25"}},
+			patch:  map[string]interface{}{"image": map[string]interface{}{"tag": "1.27"}},
+			want:   map[string]interface{}{"image": map[string]interface{}{"repository": "nginx", "tag": "1.27"}},
+		},
+		{
+			name:   "null removes a key",
+			values: map[string]interface{}{"resources": map[string]interface{}{"limits": map[string]interface{}{"cpu": "1"}}, "image": "nginx"},
+			patch:  map[string]interface{}{"resources": nil},
+			want:   map[string]interface{}{"image": "nginx"},
+		},
+		{
+			name:   "nested null",
+			values: map[string]interface{}{"image": map[string]interface{}{"repository": "nginx", "tag": "1.25"}},
+			patch:  map[string]interface{}{"image": map[string]interface{}{"tag": nil}},
+			want:   map[string]interface{}{"image": map[string]interface{}{"repository": "nginx"}},
+		},
+		{
+			name:   "object replaces a scalar, without its nulls",
+			values: map[string]interface{}{"ingress": false},
+			patch:  map[string]interface{}{"ingress": map[string]interface{}{"enabled": true, "host": nil}},
+			want:   map[string]interface{}{"ingress": map[string]interface{}{"enabled": true}},
+		},
+		{
+			name:   "arrays are replaced",
+			values: map[string]interface{}{"args": []interface{}{"--a", "--b"}},
+			patch:  map[string]interface{}{"args": []interface{}{"--c"}},
+			want:   map[string]interface{}{"args": []interface{}{"--c"}},
+		},
+		{
+			name:  "no values yet",
+			patch: map[string]interface{}{"replicaCount": 2},
+			want:  map[string]interface{}{"replicaCount": 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := deepCopyValues(tt.values)
+			got := mergeValuesPatch(tt.values, tt.patch)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mergeValuesPatch() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(tt.values, before) {
+				t.Errorf("mergeValuesPatch() modified the values to %v", tt.values)
+			}
+		})
+	}
+}
+
+func deepCopyValues(values map[string]interface{}) map[string]interface{} {
+	if values == nil {
+		return nil
+	}
+	out := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		if m, ok := v.(map[string]interface{}); ok {
+			v = deepCopyValues(m)
+		}
+		out[k] = v
+	}
+	return out
+}
@@ -6,11 +6,15 @@ package kubernetes
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
 	"k8s.io/client-go/rest"
 )
@@ -23,8 +27,10 @@ type WatchEvent struct {
 
 // ResourceWatcher handles watching Kubernetes resources
 type ResourceWatcher struct {
-	client     *Client
-	httpClient *http.Client
+	client         *Client
+	httpClient     *http.Client
+	backoffInitial time.Duration
+	backoffMax     time.Duration
 }
 
 // NewResourceWatcher creates a new ResourceWatcher
@@ -43,18 +49,137 @@ func NewResourceWatcher(client *Client) *ResourceWatcher {
 	}
 
 	return &ResourceWatcher{
-		client:     client,
-		httpClient: httpClient,
+		client:         client,
+		httpClient:     httpClient,
+		backoffInitial: watchBackoffInitial,
+		backoffMax:     watchBackoffMax,
 	}
 }
 
+// SyncedEventType is the type of the synthetic event emitted after a re-list.
+// Its object is a WatchSync; consumers should drop every object they hold for the watch
+// that is not listed in it, as it was deleted while the watch was down.
+const SyncedEventType = "SYNCED"
+
+// WatchSync is the object of a SYNCED event
+type WatchSync struct {
+	ResourceVersion string `json:"resourceVersion"`
+	// Objects holds the namespace/name (or just name for cluster scoped objects) of every listed object
+	Objects []string `json:"objects"`
+}
+
+const (
+	watchBackoffInitial = time.Second
+	watchBackoffMax     = 30 * time.Second
+	relistPageSize      = 500
+)
+
+// errWatchExpired means the API server no longer has the resourceVersion the watch would resume from
+var errWatchExpired = errors.New("watch resource version expired")
+
+// watchStatusError is a non-200 response to a watch or list request
+type watchStatusError struct {
+	path       string
+	statusCode int
+	body       string
+}
+
+func (e *watchStatusError) Error() string {
+	return fmt.Sprintf("watch request failed for path %s with status %d: %s", e.path, e.statusCode, e.body)
+}
+
+// retryable reports whether the request may succeed if repeated later.
+// 410 Gone shows up here when a re-list's continue token expires; the re-list starts over.
+func (e *watchStatusError) retryable() bool {
+	return e.statusCode == http.StatusGone ||
+		e.statusCode == http.StatusTooManyRequests ||
+		e.statusCode >= http.StatusInternalServerError
+}
+
 // WatchResource watches a Kubernetes resource at the given path
-// and sends events to the provided channel
+// and sends events to the provided channel.
+//
+// The watch is resumed from the last seen resourceVersion whenever the API server closes the stream,
+// with exponential backoff between failed attempts. Bookmarks are requested to keep the resourceVersion fresh,
+// but are not sent to the channel. If the resourceVersion has expired (410 Gone) the path is re-listed:
+// every object is sent as ADDED, followed by a SYNCED event.
+//
+// It only returns when the context is cancelled, or when the API server rejects the watch for good (e.g. 403).
 func (w *ResourceWatcher) WatchResource(ctx context.Context, path string, eventsChan chan<- *WatchEvent) error {
+	resourceVersion := ""
+	needsRelist := false
+	backoff := &watchBackoff{initial: w.backoffInitial, max: w.backoffMax}
+
+	for {
+		var (
+			received bool
+			err      error
+		)
+		if needsRelist {
+			var listed string
+			if listed, err = w.relist(ctx, path, eventsChan); err == nil {
+				resourceVersion = listed
+				needsRelist = false
+				received = true
+			}
+		} else {
+			received, err = w.watchOnce(ctx, path, &resourceVersion, eventsChan)
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		var statusErr *watchStatusError
+		switch {
+		case errors.Is(err, errWatchExpired):
+			log.Printf("Watch expired, re-listing: context=%s path=%s", w.client.CurrentContext, path)
+			needsRelist = true
+			continue
+		case errors.As(err, &statusErr) && !statusErr.retryable():
+			return err
+		}
+
+		wait := backoff.next(received)
+		if err != nil {
+			log.Printf("Watch interrupted, retrying in %s: context=%s path=%s: %v", wait, w.client.CurrentContext, path, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// watchBackoff is the exponential backoff between watch attempts
+type watchBackoff struct {
+	initial time.Duration
+	max     time.Duration
+	current time.Duration
+}
+
+// next returns how long to wait before the next attempt. The wait doubles after every attempt
+// that received nothing, up to max, and starts over from initial once an attempt received something.
+func (b *watchBackoff) next(received bool) time.Duration {
+	if received || b.current == 0 {
+		b.current = b.initial
+	}
+	wait := b.current
+	if !received {
+		b.current = min(b.current*2, b.max)
+	}
+	return wait
+}
+
+// watchOnce runs a single watch request until the stream ends. It reports whether anything was received,
+// and keeps resourceVersion up to date with the last event.
+func (w *ResourceWatcher) watchOnce(ctx context.Context, path string, resourceVersion *string, eventsChan chan<- *WatchEvent) (bool, error) {
 	// Create request
-	req, err := w.createWatchRequest(path)
+	req, err := w.createWatchRequest(path, *resourceVersion)
 	if err != nil {
-		return fmt.Errorf("error creating watch request: %w", err)
+		return false, &watchStatusError{path: path, statusCode: http.StatusBadRequest, body: err.Error()}
 	}
 
 	// Add context to request
@@ -63,22 +188,67 @@ func (w *ResourceWatcher) WatchResource(ctx context.Context, path string, events
 	// Execute request
 	resp, err := w.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("error executing watch request for path %s: %w", path, err)
+		return false, fmt.Errorf("error executing watch request for path %s: %w", path, err)
 	}
 	defer resp.Body.Close()
 
 	// Check response status
+	if resp.StatusCode == http.StatusGone {
+		return false, errWatchExpired
+	}
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("watch request failed for path %s with status %d: %s", path, resp.StatusCode, string(body))
+		return false, &watchStatusError{path: path, statusCode: resp.StatusCode, body: string(body)}
 	}
 
 	// Process the response stream
-	return w.processWatchEvents(resp.Body, eventsChan)
+	return w.processWatchEvents(ctx, resp.Body, resourceVersion, eventsChan)
 }
 
-// createWatchRequest creates an HTTP request for watching a Kubernetes resource
-func (w *ResourceWatcher) createWatchRequest(path string) (*http.Request, error) {
+// createWatchRequest creates an HTTP request for watching a Kubernetes resource,
+// resuming from resourceVersion when it is set
+func (w *ResourceWatcher) createWatchRequest(path, resourceVersion string) (*http.Request, error) {
+	u, err := w.resourceURL(path)
+	if err != nil {
+		return nil, err
+	}
+
+	q := u.Query()
+	q.Set("watch", "true")
+	q.Set("allowWatchBookmarks", "true")
+	if resourceVersion != "" {
+		q.Set("resourceVersion", resourceVersion)
+	} else {
+		q.Del("resourceVersion")
+	}
+	u.RawQuery = q.Encode()
+
+	log.Printf("Watch request: context=%s url=%s", w.client.CurrentContext, u.String())
+	return w.newRequest(u)
+}
+
+// createListRequest creates an HTTP request listing the objects at the watch path, one page at a time
+func (w *ResourceWatcher) createListRequest(path, continueToken string) (*http.Request, error) {
+	u, err := w.resourceURL(path)
+	if err != nil {
+		return nil, err
+	}
+
+	q := u.Query()
+	q.Del("watch")
+	q.Del("allowWatchBookmarks")
+	q.Del("resourceVersion")
+	q.Set("limit", strconv.Itoa(relistPageSize))
+	if continueToken != "" {
+		q.Set("continue", continueToken)
+	}
+	u.RawQuery = q.Encode()
+
+	return w.newRequest(u)
+}
+
+// resourceURL resolves a watch path against the API server URL
+func (w *ResourceWatcher) resourceURL(path string) (*url.URL, error) {
 	// Normalize the path - strip /k8s prefix if present
 	if after, ok := strings.CutPrefix(path, "/k8s"); ok {
 		path = after
@@ -89,23 +259,19 @@ func (w *ResourceWatcher) createWatchRequest(path string) (*http.Request, error)
 		path = "/" + path
 	}
 
-	// Add watch=true query parameter if not already present
-	if !strings.Contains(path, "watch=true") {
-		if strings.Contains(path, "?") {
-			path = path + "&watch=true"
-		} else {
-			path = path + "?watch=true"
-		}
-	}
-
 	// Combine the base URL with the path
-	fullURL := w.client.Config.Host + path
-	log.Printf("Watch request: context=%s url=%s", w.client.CurrentContext, fullURL)
+	u, err := url.Parse(strings.TrimSuffix(w.client.Config.Host, "/") + path)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing URL for path %s: %w", path, err)
+	}
+	return u, nil
+}
 
-	// Create request
-	req, err := http.NewRequest("GET", fullURL, nil)
+// newRequest creates an authenticated GET request
+func (w *ResourceWatcher) newRequest(u *url.URL) (*http.Request, error) {
+	req, err := http.NewRequest("GET", u.String(), nil)
 	if err != nil {
-		return nil, fmt.Errorf("error creating request for path %s: %w", path, err)
+		return nil, fmt.Errorf("error creating request for %s: %w", u.Path, err)
 	}
 
 	// Set up authentication
@@ -129,21 +295,201 @@ func (w *ResourceWatcher) setupAuth(req *http.Request) {
 	}
 }
 
-// processWatchEvents reads the watch event stream and sends events to the channel
-func (w *ResourceWatcher) processWatchEvents(reader io.Reader, eventsChan chan<- *WatchEvent) error {
+// processWatchEvents reads the watch event stream and sends events to the channel.
+// Bookmarks only move resourceVersion forward. An ERROR event ends the stream:
+// a 410 Gone status is reported as errWatchExpired, anything else as a retryable error.
+func (w *ResourceWatcher) processWatchEvents(ctx context.Context, reader io.Reader, resourceVersion *string, eventsChan chan<- *WatchEvent) (bool, error) {
+	received := false
 	decoder := json.NewDecoder(reader)
 	for {
 		event := &WatchEvent{}
 		if err := decoder.Decode(event); err != nil {
 			if err == io.EOF {
-				return nil
+				return received, nil
+			}
+			return received, fmt.Errorf("error decoding watch event: %w", err)
+		}
+		received = true
+
+		if event.Type == "ERROR" {
+			var status struct {
+				Code    int    `json:"code"`
+				Reason  string `json:"reason"`
+				Message string `json:"message"`
 			}
-			return fmt.Errorf("error decoding watch event: %w", err)
+			_ = json.Unmarshal(event.Object, &status)
+			if status.Code == http.StatusGone || status.Reason == "Expired" || status.Reason == "Gone" {
+				return received, errWatchExpired
+			}
+			return received, fmt.Errorf("watch error event: %s (%d)", status.Message, status.Code)
+		}
+
+		if rv := objectResourceVersion(event.Object); rv != "" {
+			*resourceVersion = rv
+		}
+		if event.Type == "BOOKMARK" {
+			continue
 		}
 
 		// Send event to channel
-		eventsChan <- event
+		select {
+		case eventsChan <- event:
+		case <-ctx.Done():
+			return received, ctx.Err()
+		}
+	}
+}
+
+// relist lists every object at the watch path, sends them as ADDED events followed by a SYNCED event,
+// and returns the resourceVersion to resume watching from
+func (w *ResourceWatcher) relist(ctx context.Context, path string, eventsChan chan<- *WatchEvent) (string, error) {
+	var (
+		items           []json.RawMessage
+		resourceVersion string
+		continueToken   string
+	)
+
+	for {
+		page, err := w.listPage(ctx, path, continueToken)
+		if err != nil {
+			return "", err
+		}
+		items = append(items, page.Items...)
+		resourceVersion = page.ResourceVersion
+		continueToken = page.Continue
+		if continueToken == "" {
+			break
+		}
+	}
+
+	synced := WatchSync{ResourceVersion: resourceVersion, Objects: make([]string, 0, len(items))}
+	for _, item := range items {
+		if name := ObjectNamespacedName(item); name != "" {
+			synced.Objects = append(synced.Objects, name)
+		}
+		select {
+		case eventsChan <- &WatchEvent{Type: "ADDED", Object: item}:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+
+	syncObject, err := json.Marshal(synced)
+	if err != nil {
+		return "", fmt.Errorf("error encoding sync event: %w", err)
+	}
+	select {
+	case eventsChan <- &WatchEvent{Type: SyncedEventType, Object: syncObject}:
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+
+	return resourceVersion, nil
+}
+
+// relistPage is one page of a re-list
+type relistPage struct {
+	Items           []json.RawMessage
+	ResourceVersion string
+	Continue        string
+}
+
+// listPage fetches one page of objects at the watch path. Items in list responses lack kind and apiVersion,
+// they are filled in from the list so the objects look the same as the ones in watch events.
+// A path that points at a single object yields a page with just that object.
+func (w *ResourceWatcher) listPage(ctx context.Context, path, continueToken string) (*relistPage, error) {
+	req, err := w.createListRequest(path, continueToken)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := w.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("error executing list request for path %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading list response for path %s: %w", path, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &watchStatusError{path: path, statusCode: resp.StatusCode, body: string(body)}
+	}
+
+	var list struct {
+		Kind       string `json:"kind"`
+		APIVersion string `json:"apiVersion"`
+		Metadata   struct {
+			ResourceVersion string `json:"resourceVersion"`
+			Continue        string `json:"continue"`
+		} `json:"metadata"`
+		Items *[]map[string]json.RawMessage `json:"items"`
+	}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, fmt.Errorf("error decoding list response for path %s: %w", path, err)
+	}
+
+	if list.Items == nil {
+		// A single object rather than a list
+		return &relistPage{
+			Items:           []json.RawMessage{body},
+			ResourceVersion: list.Metadata.ResourceVersion,
+		}, nil
+	}
+
+	itemKind, _ := json.Marshal(strings.TrimSuffix(list.Kind, "List"))
+	itemAPIVersion, _ := json.Marshal(list.APIVersion)
+
+	page := &relistPage{
+		Items:           make([]json.RawMessage, 0, len(*list.Items)),
+		ResourceVersion: list.Metadata.ResourceVersion,
+		Continue:        list.Metadata.Continue,
+	}
+	for _, item := range *list.Items {
+		if _, ok := item["kind"]; !ok {
+			item["kind"] = itemKind
+		}
+		if _, ok := item["apiVersion"]; !ok {
+			item["apiVersion"] = itemAPIVersion
+		}
+		raw, err := json.Marshal(item)
+		if err != nil {
+			return nil, fmt.Errorf("error encoding listed object: %w", err)
+		}
+		page.Items = append(page.Items, raw)
+	}
+	return page, nil
+}
+
+// objectMeta is the part of an object's metadata the watcher cares about
+type objectMeta struct {
+	Metadata struct {
+		Name            string `json:"name"`
+		Namespace       string `json:"namespace"`
+		ResourceVersion string `json:"resourceVersion"`
+	} `json:"metadata"`
+}
+
+func objectResourceVersion(raw json.RawMessage) string {
+	var obj objectMeta
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return ""
+	}
+	return obj.Metadata.ResourceVersion
+}
+
+// ObjectNamespacedName returns namespace/name of an object, or just the name for cluster scoped objects,
+// in the form used by WatchSync
+func ObjectNamespacedName(raw json.RawMessage) string {
+	var obj objectMeta
+	if err := json.Unmarshal(raw, &obj); err != nil || obj.Metadata.Name == "" {
+		return ""
+	}
+	if obj.Metadata.Namespace == "" {
+		return obj.Metadata.Name
 	}
+	return obj.Metadata.Namespace + "/" + obj.Metadata.Name
 }
 
 // WatchPath is a helper function that creates a ResourceWatcher and starts watching
@@ -0,0 +1,366 @@
+// Copyright 2025 Laszlo Consulting Kft.
+// SPDX-License-Identifier: Apache-2.0
+
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// WatchMultiplexer shares upstream watches between subscribers.
+// There is at most one upstream watch per context and path, no matter how many
+// WebSocket connections subscribe to it. Each shared watch keeps the objects it has seen
+// in memory, so a new subscriber gets the current state right away, followed by live events.
+// Subscribers get the events of a re-list as if the watch had never been interrupted (see broadcast).
+// The upstream watch is stopped when its last subscriber leaves.
+type WatchMultiplexer struct {
+	mu      sync.Mutex
+	watches map[string]*sharedWatch
+}
+
+// NewWatchMultiplexer creates an empty WatchMultiplexer
+func NewWatchMultiplexer() *WatchMultiplexer {
+	return &WatchMultiplexer{
+		watches: make(map[string]*sharedWatch),
+	}
+}
+
+// sharedWatch is a single upstream watch with its object cache and subscribers
+type sharedWatch struct {
+	mux    *WatchMultiplexer
+	key    string
+	cancel context.CancelFunc
+
+	mu          sync.Mutex
+	objects     map[string]json.RawMessage // keyed by objectKey
+	order       []string                   // object keys in the order they were first seen, for a stable snapshot
+	subscribers map[*WatchSubscription]struct{}
+	done        bool
+}
+
+// WatchSubscription receives the events of a shared watch
+type WatchSubscription struct {
+	shared *sharedWatch
+	events chan *WatchEvent
+
+	mu      sync.Mutex
+	pending []*WatchEvent
+	notify  chan struct{}
+	closed  chan struct{}
+	once    sync.Once
+	err     error
+	ended   bool
+}
+
+// Subscribe joins the shared watch of the given client's context and path,
+// starting the upstream watch if this is the first subscriber.
+// The subscription must be closed with Close when it is no longer needed.
+func (m *WatchMultiplexer) Subscribe(client *Client, path string) *WatchSubscription {
+	key := client.CurrentContext + "|" + path
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sub := newWatchSubscription()
+	if w, ok := m.watches[key]; ok && w.addSubscriber(sub) {
+		return sub
+	}
+
+	// First subscriber, or the previous watch for the key is just shutting down
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &sharedWatch{
+		mux:         m,
+		key:         key,
+		cancel:      cancel,
+		objects:     make(map[string]json.RawMessage),
+		subscribers: make(map[*WatchSubscription]struct{}),
+	}
+	m.watches[key] = w
+	w.addSubscriber(sub)
+	go w.run(ctx, client, path)
+	return sub
+}
+
+// Watches returns the number of upstream watches currently running
+func (m *WatchMultiplexer) Watches() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.watches)
+}
+
+// remove drops the shared watch from the multiplexer if it is still the registered one for its key
+func (m *WatchMultiplexer) remove(w *sharedWatch) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.watches[w.key] == w {
+		delete(m.watches, w.key)
+	}
+}
+
+// run keeps the upstream watch going and fans its events out until it ends or is cancelled
+func (w *sharedWatch) run(ctx context.Context, client *Client, path string) {
+	eventsChan := make(chan *WatchEvent, 100)
+	errChan := make(chan error, 1)
+
+	go func() {
+		defer close(eventsChan)
+		errChan <- client.WatchPath(ctx, path, eventsChan)
+	}()
+
+	for event := range eventsChan {
+		w.broadcast(event)
+	}
+
+	err := <-errChan
+	if ctx.Err() != nil {
+		// Torn down after the last subscriber left
+		return
+	}
+	if err == nil {
+		err = fmt.Errorf("watch closed by the API server")
+	}
+	log.Printf("Shared watch ended [key=%s]: %v", w.key, err)
+	w.end(err)
+}
+
+// addSubscriber queues the cached snapshot for the subscriber and registers it for live events.
+// Both happen under the same lock as broadcast, so the subscriber sees no gap and no duplicates.
+// It returns false if the watch has already ended.
+func (w *sharedWatch) addSubscriber(sub *WatchSubscription) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.done {
+		return false
+	}
+
+	sub.shared = w
+	for _, key := range w.order {
+		sub.enqueue(&WatchEvent{Type: "ADDED", Object: w.objects[key]})
+	}
+	w.subscribers[sub] = struct{}{}
+	return true
+}
+
+// removeSubscriber unregisters the subscriber and tears the upstream watch down if it was the last one
+func (w *sharedWatch) removeSubscriber(sub *WatchSubscription) {
+	w.mu.Lock()
+	delete(w.subscribers, sub)
+	last := len(w.subscribers) == 0 && !w.done
+	if last {
+		w.done = true
+	}
+	w.mu.Unlock()
+
+	if last {
+		w.mux.remove(w)
+		w.cancel()
+	}
+}
+
+// broadcast updates the object cache and queues the event for every subscriber.
+// A re-list sends every object as ADDED again, those the cache already holds go out as MODIFIED,
+// and the objects a SYNCED event prunes go out as DELETED ahead of it, so subscribers that ignore
+// SYNCED don't end up with duplicates or with objects deleted while the watch was down.
+func (w *sharedWatch) broadcast(event *WatchEvent) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	events := []*WatchEvent{event}
+	if event.Type == SyncedEventType {
+		events = append(w.prune(event.Object), event)
+	} else if key, ok := objectKey(event.Object); ok {
+		_, cached := w.objects[key]
+		switch event.Type {
+		case "ADDED", "MODIFIED":
+			if !cached {
+				w.order = append(w.order, key)
+			} else if event.Type == "ADDED" {
+				events[0] = &WatchEvent{Type: "MODIFIED", Object: event.Object}
+			}
+			w.objects[key] = event.Object
+		case "DELETED":
+			if cached {
+				delete(w.objects, key)
+				w.order = removeKey(w.order, key)
+			}
+		}
+	}
+
+	for sub := range w.subscribers {
+		for _, ev := range events {
+			sub.enqueue(ev)
+		}
+	}
+}
+
+// prune drops the cached objects that are missing from the re-list described by a SYNCED event,
+// and returns a DELETED event with the last known state of each
+func (w *sharedWatch) prune(raw json.RawMessage) []*WatchEvent {
+	var synced WatchSync
+	if err := json.Unmarshal(raw, &synced); err != nil {
+		return nil
+	}
+	listed := make(map[string]struct{}, len(synced.Objects))
+	for _, name := range synced.Objects {
+		listed[name] = struct{}{}
+	}
+
+	var deleted []*WatchEvent
+	order := w.order[:0]
+	for _, key := range w.order {
+		if _, ok := listed[ObjectNamespacedName(w.objects[key])]; ok {
+			order = append(order, key)
+		} else {
+			deleted = append(deleted, &WatchEvent{Type: "DELETED", Object: w.objects[key]})
+			delete(w.objects, key)
+		}
+	}
+	w.order = order
+	return deleted
+}
+
+// end closes every subscription with the given error after the upstream watch has stopped
+func (w *sharedWatch) end(err error) {
+	w.mux.remove(w)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.done = true
+	for sub := range w.subscribers {
+		sub.end(err)
+	}
+	w.subscribers = make(map[*WatchSubscription]struct{})
+}
+
+// objectKey identifies an object in the cache by UID, or by namespace and name when there is no UID
+func objectKey(raw json.RawMessage) (string, bool) {
+	if len(raw) == 0 {
+		return "", false
+	}
+	var obj struct {
+		Kind     string `json:"kind"`
+		Metadata struct {
+			UID       string `json:"uid"`
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return "", false
+	}
+	// Status objects come with ERROR events, they are not cached
+	if obj.Kind == "Status" {
+		return "", false
+	}
+	if obj.Metadata.UID != "" {
+		return obj.Metadata.UID, true
+	}
+	if obj.Metadata.Name == "" {
+		return "", false
+	}
+	return obj.Metadata.Namespace + "/" + obj.Metadata.Name, true
+}
+
+func removeKey(keys []string, key string) []string {
+	for i, k := range keys {
+		if k == key {
+			return append(keys[:i], keys[i+1:]...)
+		}
+	}
+	return keys
+}
+
+func newWatchSubscription() *WatchSubscription {
+	sub := &WatchSubscription{
+		events: make(chan *WatchEvent),
+		notify: make(chan struct{}, 1),
+		closed: make(chan struct{}),
+	}
+	go sub.pump()
+	return sub
+}
+
+// Events returns the channel the snapshot and the live events are delivered on.
+// It is closed when the upstream watch ends (see Err) or the subscription is closed.
+func (s *WatchSubscription) Events() <-chan *WatchEvent {
+	return s.events
+}
+
+// Err returns why the upstream watch ended, once Events is closed
+func (s *WatchSubscription) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Close leaves the shared watch
+func (s *WatchSubscription) Close() {
+	s.once.Do(func() {
+		close(s.closed)
+		s.shared.removeSubscriber(s)
+	})
+}
+
+// enqueue adds an event to the subscriber's queue without blocking the broadcaster,
+// so a slow WebSocket connection doesn't hold up the others
+func (s *WatchSubscription) enqueue(event *WatchEvent) {
+	s.mu.Lock()
+	s.pending = append(s.pending, event)
+	s.mu.Unlock()
+	s.wake()
+}
+
+// end marks the subscription as ended; Events is closed once the queued events are delivered
+func (s *WatchSubscription) end(err error) {
+	s.mu.Lock()
+	s.err = err
+	s.ended = true
+	s.mu.Unlock()
+	s.wake()
+}
+
+func (s *WatchSubscription) wake() {
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// pump delivers the queued events to the Events channel in order
+func (s *WatchSubscription) pump() {
+	defer close(s.events)
+	for {
+		s.mu.Lock()
+		batch := s.pending
+		s.pending = nil
+		ended := s.ended
+		s.mu.Unlock()
+
+		for _, event := range batch {
+			select {
+			case s.events <- event:
+			case <-s.closed:
+				return
+			}
+		}
+
+		if len(batch) > 0 {
+			continue
+		}
+		if ended {
+			return
+		}
+
+		select {
+		case <-s.notify:
+		case <-s.closed:
+			return
+		}
+	}
+}
@@ -0,0 +1,205 @@
+// Copyright 2025 Laszlo Consulting Kft.
+// SPDX-License-Identifier: Apache-2.0
+
+package kubernetes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/rest"
+)
+
+func newTestSharedWatch() *sharedWatch {
+	return &sharedWatch{
+		mux:         NewWatchMultiplexer(),
+		cancel:      func() {},
+		objects:     make(map[string]json.RawMessage),
+		subscribers: make(map[*WatchSubscription]struct{}),
+	}
+}
+
+func podEvent(typ, name string) *WatchEvent {
+	return &WatchEvent{Type: typ, Object: json.RawMessage(pod(name, "1"))}
+}
+
+func syncEvent(names ...string) *WatchEvent {
+	obj, _ := json.Marshal(WatchSync{ResourceVersion: "10", Objects: names})
+	return &WatchEvent{Type: SyncedEventType, Object: obj}
+}
+
+// receive reads n events from the subscription, then makes sure no more are queued
+func receive(t *testing.T, sub *WatchSubscription, n int) []string {
+	t.Helper()
+	var events []*WatchEvent
+	for len(events) < n {
+		select {
+		case ev, ok := <-sub.Events():
+			if !ok {
+				t.Fatalf("events closed after %v", formatWatchEvents(t, events))
+			}
+			events = append(events, ev)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("got %v, want %d events", formatWatchEvents(t, events), n)
+		}
+	}
+	select {
+	case ev := <-sub.Events():
+		t.Fatalf("unexpected event %v after %v", formatWatchEvents(t, []*WatchEvent{ev}), formatWatchEvents(t, events))
+	case <-time.After(10 * time.Millisecond):
+	}
+	return formatWatchEvents(t, events)
+}
+
+func TestSharedWatchBroadcast(t *testing.T) {
+	w := newTestSharedWatch()
+	sub := newWatchSubscription()
+	if !w.addSubscriber(sub) {
+		t.Fatal("addSubscriber() = false, want true")
+	}
+	defer close(sub.closed)
+
+	steps := []struct {
+		name   string
+		event  *WatchEvent
+		want   []string
+		cached []string
+	}{
+		{name: "new object", event: podEvent("ADDED", "a"), want: []string{"ADDED Pod apps/a"}, cached: []string{"apps/a"}},
+		{name: "another object", event: podEvent("ADDED", "b"), want: []string{"ADDED Pod apps/b"}, cached: []string{"apps/a", "apps/b"}},
+		{name: "change", event: podEvent("MODIFIED", "a"), want: []string{"MODIFIED Pod apps/a"}, cached: []string{"apps/a", "apps/b"}},
+		{name: "re-listed object the subscribers have", event: podEvent("ADDED", "a"), want: []string{"MODIFIED Pod apps/a"}, cached: []string{"apps/a", "apps/b"}},
+		{name: "re-listed object created during the gap", event: podEvent("ADDED", "c"), want: []string{"ADDED Pod apps/c"}, cached: []string{"apps/a", "apps/b", "apps/c"}},
+		{
+			name:   "re-list without an object deleted during the gap",
+			event:  syncEvent("apps/a", "apps/c"),
+			want:   []string{"DELETED Pod apps/b", "SYNCED 10 apps/a,apps/c"},
+			cached: []string{"apps/a", "apps/c"},
+		},
+		{name: "deletion", event: podEvent("DELETED", "a"), want: []string{"DELETED Pod apps/a"}, cached: []string{"apps/c"}},
+		{
+			name:   "error events are not cached",
+			event:  &WatchEvent{Type: "ERROR", Object: json.RawMessage(`{"kind":"Status","code":500}`)},
+			want:   []string{"ERROR Status "},
+			cached: []string{"apps/c"},
+		},
+	}
+
+	for _, step := range steps {
+		w.broadcast(step.event)
+		if got := receive(t, sub, len(step.want)); fmt.Sprint(got) != fmt.Sprint(step.want) {
+			t.Fatalf("%s: got %v, want %v", step.name, got, step.want)
+		}
+		var cached []string
+		for _, key := range w.order {
+			cached = append(cached, ObjectNamespacedName(w.objects[key]))
+		}
+		if fmt.Sprint(cached) != fmt.Sprint(step.cached) {
+			t.Fatalf("%s: cached %v, want %v", step.name, cached, step.cached)
+		}
+	}
+}
+
+func TestSharedWatchLateSubscriber(t *testing.T) {
+	w := newTestSharedWatch()
+	early := newWatchSubscription()
+	w.addSubscriber(early)
+	defer close(early.closed)
+
+	w.broadcast(podEvent("ADDED", "b"))
+	w.broadcast(podEvent("ADDED", "a"))
+	w.broadcast(podEvent("MODIFIED", "b"))
+	receive(t, early, 3)
+
+	late := newWatchSubscription()
+	w.addSubscriber(late)
+	defer close(late.closed)
+	if got, want := receive(t, late, 2), []string{"ADDED Pod apps/b", "ADDED Pod apps/a"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("snapshot = %v, want %v", got, want)
+	}
+
+	w.broadcast(podEvent("DELETED", "a"))
+	for name, sub := range map[string]*WatchSubscription{"early": early, "late": late} {
+		if got := receive(t, sub, 1); fmt.Sprint(got) != fmt.Sprint([]string{"DELETED Pod apps/a"}) {
+			t.Errorf("%s subscriber got %v, want the deletion", name, got)
+		}
+	}
+}
+
+func TestWatchMultiplexer(t *testing.T) {
+	var watches atomic.Int32
+	cancelled := make(chan struct{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		watches.Add(1)
+		fmt.Fprintln(w, watchEvent("ADDED", pod("a", "1")))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+		cancelled <- struct{}{}
+	}))
+	defer srv.Close()
+	client := &Client{Config: &rest.Config{Host: srv.URL}, CurrentContext: "test"}
+
+	mux := NewWatchMultiplexer()
+	first := mux.Subscribe(client, podsPath)
+	if got := receive(t, first, 1); fmt.Sprint(got) != fmt.Sprint([]string{"ADDED Pod apps/a"}) {
+		t.Fatalf("first subscriber got %v, want the live event", got)
+	}
+
+	second := mux.Subscribe(client, podsPath)
+	if got := receive(t, second, 1); fmt.Sprint(got) != fmt.Sprint([]string{"ADDED Pod apps/a"}) {
+		t.Fatalf("second subscriber got %v, want the cached object", got)
+	}
+	if mux.Watches() != 1 || watches.Load() != 1 {
+		t.Fatalf("%d shared watches and %d upstream watches, want one of each", mux.Watches(), watches.Load())
+	}
+
+	first.Close()
+	select {
+	case <-cancelled:
+		t.Fatal("upstream watch cancelled while a subscriber is left")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	second.Close()
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("upstream watch not cancelled after the last subscriber left")
+	}
+	if mux.Watches() != 0 {
+		t.Errorf("%d shared watches after the last subscriber left, want none", mux.Watches())
+	}
+}
+
+func TestWatchMultiplexerUpstreamEnds(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, "pods is forbidden")
+	}))
+	defer srv.Close()
+	client := &Client{Config: &rest.Config{Host: srv.URL}, CurrentContext: "test"}
+
+	mux := NewWatchMultiplexer()
+	sub := mux.Subscribe(client, podsPath)
+	defer sub.Close()
+	select {
+	case _, ok := <-sub.Events():
+		if ok {
+			t.Fatal("got an event, want the subscription to end")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("subscription didn't end")
+	}
+	if err := sub.Err(); err == nil || !strings.Contains(err.Error(), "forbidden") {
+		t.Errorf("Err() = %v, want the upstream error", err)
+	}
+	if mux.Watches() != 0 {
+		t.Errorf("%d shared watches after the upstream ended, want none", mux.Watches())
+	}
+}
@@ -0,0 +1,251 @@
+// Copyright 2025 Laszlo Consulting Kft.
+// SPDX-License-Identifier: Apache-2.0
+
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/rest"
+)
+
+const podsPath = "/api/v1/namespaces/apps/pods"
+
+// apiStep is a request the fake API server expects, and its response
+type apiStep struct {
+	request string // "watch rv=<resourceVersion>" or "list continue=<token>"
+	status  int
+	body    string
+}
+
+// fakeAPIServer serves the steps in order and fails the test on any other request
+func fakeAPIServer(t *testing.T, steps []apiStep) *Client {
+	t.Helper()
+	var (
+		mu   sync.Mutex
+		next int
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if r.URL.Path != podsPath {
+			t.Errorf("request for %s, want %s", r.URL.Path, podsPath)
+		}
+		if next >= len(steps) {
+			t.Errorf("unexpected request %q", describeRequest(r))
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		step := steps[next]
+		next++
+		if got := describeRequest(r); got != step.request {
+			t.Errorf("request %d = %q, want %q", next, got, step.request)
+		}
+		w.WriteHeader(step.status)
+		fmt.Fprint(w, step.body)
+	}))
+	t.Cleanup(func() {
+		srv.Close()
+		if next != len(steps) {
+			t.Errorf("served %d of %d requests", next, len(steps))
+		}
+	})
+	return &Client{Config: &rest.Config{Host: srv.URL}, CurrentContext: "test"}
+}
+
+func describeRequest(r *http.Request) string {
+	q := r.URL.Query()
+	if q.Get("watch") == "true" {
+		return "watch rv=" + q.Get("resourceVersion")
+	}
+	if q.Get("limit") != fmt.Sprint(relistPageSize) {
+		return "list without a page size"
+	}
+	return "list continue=" + q.Get("continue")
+}
+
+func pod(name, resourceVersion string) string {
+	return fmt.Sprintf(`{"kind":"Pod","apiVersion":"v1","metadata":{"name":%q,"namespace":"apps","resourceVersion":%q}}`, name, resourceVersion)
+}
+
+// listedPod is a pod as list responses have it, without kind and apiVersion
+func listedPod(name string) string {
+	return fmt.Sprintf(`{"metadata":{"name":%q,"namespace":"apps"}}`, name)
+}
+
+func watchStream(events ...string) string {
+	return strings.Join(events, "\n")
+}
+
+func watchEvent(typ, object string) string {
+	return fmt.Sprintf(`{"type":%q,"object":%s}`, typ, object)
+}
+
+func podList(resourceVersion, continueToken string, items ...string) string {
+	return fmt.Sprintf(`{"kind":"PodList","apiVersion":"v1","metadata":{"resourceVersion":%q,"continue":%q},"items":[%s]}`,
+		resourceVersion, continueToken, strings.Join(items, ","))
+}
+
+// formatWatchEvents formats events as "TYPE Kind namespace/name", and SYNCED events as "SYNCED resourceVersion names"
+func formatWatchEvents(t *testing.T, events []*WatchEvent) []string {
+	t.Helper()
+	var out []string
+	for _, ev := range events {
+		if ev.Type == SyncedEventType {
+			var synced WatchSync
+			if err := json.Unmarshal(ev.Object, &synced); err != nil {
+				t.Fatal(err)
+			}
+			out = append(out, fmt.Sprintf("SYNCED %s %s", synced.ResourceVersion, strings.Join(synced.Objects, ",")))
+			continue
+		}
+		var obj struct {
+			Kind string `json:"kind"`
+		}
+		if err := json.Unmarshal(ev.Object, &obj); err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, fmt.Sprintf("%s %s %s", ev.Type, obj.Kind, ObjectNamespacedName(ev.Object)))
+	}
+	return out
+}
+
+func TestWatchResource(t *testing.T) {
+	forbidden := func(rv string) apiStep {
+		return apiStep{request: "watch rv=" + rv, status: http.StatusForbidden, body: "forbidden"}
+	}
+
+	tests := []struct {
+		name  string
+		steps []apiStep
+		want  []string
+	}{
+		{
+			name: "resumes from the last resource version",
+			steps: []apiStep{
+				{request: "watch rv=", status: http.StatusOK, body: watchStream(
+					watchEvent("ADDED", pod("a", "1")),
+					watchEvent("BOOKMARK", `{"kind":"Pod","metadata":{"resourceVersion":"5"}}`),
+				)},
+				{request: "watch rv=5", status: http.StatusOK, body: watchEvent("MODIFIED", pod("a", "6"))},
+				forbidden("6"),
+			},
+			want: []string{"ADDED Pod apps/a", "MODIFIED Pod apps/a"},
+		},
+		{
+			name: "retries server errors",
+			steps: []apiStep{
+				{request: "watch rv=", status: http.StatusInternalServerError},
+				{request: "watch rv=", status: http.StatusTooManyRequests},
+				{request: "watch rv=", status: http.StatusOK, body: watchEvent("ADDED", pod("a", "1"))},
+				forbidden("1"),
+			},
+			want: []string{"ADDED Pod apps/a"},
+		},
+		{
+			name: "410 status re-lists page by page",
+			steps: []apiStep{
+				{request: "watch rv=", status: http.StatusOK, body: watchEvent("ADDED", pod("a", "1"))},
+				{request: "watch rv=1", status: http.StatusGone},
+				{request: "list continue=", status: http.StatusOK, body: podList("10", "page2", listedPod("a"))},
+				{request: "list continue=page2", status: http.StatusOK, body: podList("10", "", listedPod("b"))},
+				forbidden("10"),
+			},
+			want: []string{"ADDED Pod apps/a", "ADDED Pod apps/a", "ADDED Pod apps/b", "SYNCED 10 apps/a,apps/b"},
+		},
+		{
+			name: "410 error event re-lists",
+			steps: []apiStep{
+				{request: "watch rv=", status: http.StatusOK, body: watchStream(
+					watchEvent("ADDED", pod("a", "1")),
+					watchEvent("ADDED", pod("b", "2")),
+					watchEvent("ERROR", `{"kind":"Status","code":410,"reason":"Expired","message":"too old resource version"}`),
+				)},
+				{request: "list continue=", status: http.StatusOK, body: podList("20", "", listedPod("b"))},
+				forbidden("20"),
+			},
+			want: []string{"ADDED Pod apps/a", "ADDED Pod apps/b", "ADDED Pod apps/b", "SYNCED 20 apps/b"},
+		},
+		{
+			name: "expired continue token restarts the re-list",
+			steps: []apiStep{
+				{request: "watch rv=", status: http.StatusGone},
+				{request: "list continue=", status: http.StatusOK, body: podList("30", "page2", listedPod("a"))},
+				{request: "list continue=page2", status: http.StatusGone},
+				{request: "list continue=", status: http.StatusOK, body: podList("31", "", listedPod("a"))},
+				forbidden("31"),
+			},
+			want: []string{"ADDED Pod apps/a", "SYNCED 31 apps/a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			watcher := NewResourceWatcher(fakeAPIServer(t, tt.steps))
+			watcher.backoffInitial, watcher.backoffMax = time.Millisecond, 4*time.Millisecond
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			events := make(chan *WatchEvent, 100)
+			err := watcher.WatchResource(ctx, podsPath, events)
+			if err == nil || !strings.Contains(err.Error(), "status 403") {
+				t.Fatalf("WatchResource() error = %v, want the 403 that ends the watch", err)
+			}
+
+			close(events)
+			var got []*WatchEvent
+			for ev := range events {
+				got = append(got, ev)
+			}
+			if formatted := formatWatchEvents(t, got); fmt.Sprint(formatted) != fmt.Sprint(tt.want) {
+				t.Errorf("events = %v, want %v", formatted, tt.want)
+			}
+		})
+	}
+}
+
+func TestWatchBackoff(t *testing.T) {
+	tests := []struct {
+		name     string
+		received []bool
+		want     []time.Duration
+	}{
+		{
+			name:     "doubles up to the maximum",
+			received: []bool{false, false, false, false},
+			want:     []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second},
+		},
+		{
+			name:     "starts over after receiving",
+			received: []bool{false, false, true, false, false},
+			want:     []time.Duration{time.Second, 2 * time.Second, time.Second, time.Second, 2 * time.Second},
+		},
+		{
+			name:     "streams that keep delivering reconnect quickly",
+			received: []bool{true, true},
+			want:     []time.Duration{time.Second, time.Second},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backoff := &watchBackoff{initial: time.Second, max: 4 * time.Second}
+			var got []time.Duration
+			for _, received := range tt.received {
+				got = append(got, backoff.next(received))
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("waits = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,375 @@
+// Copyright 2025 Laszlo Consulting Kft.
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os/user"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gimlet-io/capacitor/pkg/audit"
+	"github.com/gimlet-io/capacitor/pkg/helm"
+	"github.com/labstack/echo/v4"
+)
+
+// maxAuditBodySize caps how much of a request body is read to find the audit target
+const maxAuditBodySize = 1 << 20
+
+// auditDefaultKinds is the target kind of actions whose request doesn't carry one
+var auditDefaultKinds = map[string]string{
+	"cronjob.run":    "CronJob",
+	"helm.rollback":  "HelmRelease",
+	"helm.upgrade":   "HelmRelease",
+	"helm.uninstall": "HelmRelease",
+	"helm.test":      "HelmRelease",
+	"exec":           "Pod",
+	"node-debug":     "Node",
+}
+
+// currentOSUser returns the name of the user running the process, or an empty string if it can't be determined
+func currentOSUser() string {
+	u, err := user.Current()
+	if err != nil {
+		return ""
+	}
+	return u.Username
+}
+
+// mutating is a route middleware for endpoints that change cluster state.
+// It records the action in the audit log under the given verb,
+// and in read-only mode refuses it before the handler runs.
+func (s *Server) mutating(verb string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			entry := s.newAuditEntry(c, verb)
+			entry.Target, entry.Details = auditTargetFromRequest(c)
+			if entry.Target.Kind == "" {
+				entry.Target.Kind = auditDefaultKinds[verb]
+			}
+
+			var err error
+			if s.config.ReadOnly {
+				err = c.JSON(http.StatusForbidden, map[string]string{
+					"error": "Capacitor is running in read-only mode",
+				})
+			} else {
+				err = next(c)
+			}
+
+			s.recordAudit(c, entry, start, err)
+			return err
+		}
+	}
+}
+
+// auditProxyWrites is a route middleware for the Kubernetes API proxy that records every
+// request that isn't a GET or HEAD. The verb is the Kubernetes verb the HTTP method maps to.
+func (s *Server) auditProxyWrites(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+		if req.Method == http.MethodGet || req.Method == http.MethodHead {
+			return next(c)
+		}
+
+		start := time.Now()
+		target := auditTargetFromAPIPath("/" + c.Param("*"))
+		if target.Name == "" && req.Method == http.MethodPost {
+			if body := peekRequestBody(req); body != nil {
+				var obj struct {
+					Kind     string `json:"kind"`
+					Metadata struct {
+						Name string `json:"name"`
+					} `json:"metadata"`
+				}
+				if json.Unmarshal(body, &obj) == nil {
+					target.Kind = obj.Kind
+					target.Name = obj.Metadata.Name
+				}
+			}
+		}
+
+		entry := s.newAuditEntry(c, kubernetesVerb(req.Method, target.Name))
+		entry.Target = target
+
+		err := next(c)
+		s.recordAudit(c, entry, start, err)
+		return err
+	}
+}
+
+// auditJob returns a finish hook for a background Helm job started by the request. The entry of the request
+// only records that the job was accepted, the hook records a second entry with the outcome of the job itself.
+// The entry is prepared right away, as the echo context is reused once the request is done.
+func (s *Server) auditJob(c echo.Context, verb string) func(helm.Job) {
+	entry := s.newAuditEntry(c, verb)
+	entry.Target, entry.Details = auditTargetFromRequest(c)
+	if entry.Target.Kind == "" {
+		entry.Target.Kind = auditDefaultKinds[verb]
+	}
+
+	return func(job helm.Job) {
+		entry.Time = time.Now()
+		if job.FinishedAt != nil {
+			entry.Time = *job.FinishedAt
+		}
+		entry.DurationMs = entry.Time.Sub(job.StartedAt).Milliseconds()
+		if entry.Details == nil {
+			entry.Details = map[string]string{}
+		}
+		entry.Details["job"] = job.ID
+		entry.Outcome = audit.OutcomeSuccess
+		if job.Status == helm.JobFailed {
+			entry.Outcome = audit.OutcomeFailure
+			entry.Error = job.Error
+		}
+
+		if err := s.audit.Record(entry); err != nil {
+			log.Printf("Failed to write audit log entry: %v", err)
+		}
+	}
+}
+
+// newAuditEntry fills in who performed the action and in which context
+func (s *Server) newAuditEntry(c echo.Context, verb string) audit.Entry {
+	entry := audit.Entry{
+		Verb:       verb,
+		OSUser:     s.osUser,
+		RemoteAddr: c.RealIP(),
+	}
+
+	if ctxName, err := url.PathUnescape(c.Param("context")); err == nil {
+		entry.Context = ctxName
+	}
+	if proxy, ok := getProxyFromContext(c); ok && proxy.k8sClient.ContextConfig != nil {
+		entry.User = proxy.k8sClient.ContextConfig.AuthInfo
+	}
+
+	return entry
+}
+
+// recordAudit completes the entry with the outcome of the request and writes it to the audit log
+func (s *Server) recordAudit(c echo.Context, entry audit.Entry, start time.Time, err error) {
+	entry.Time = start
+	entry.DurationMs = time.Since(start).Milliseconds()
+	entry.Status = c.Response().Status
+
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		entry.Status = httpErr.Code
+	}
+
+	switch {
+	case entry.Status == http.StatusUnauthorized || entry.Status == http.StatusForbidden:
+		entry.Outcome = audit.OutcomeDenied
+	case err != nil || entry.Status >= http.StatusBadRequest:
+		entry.Outcome = audit.OutcomeFailure
+	default:
+		entry.Outcome = audit.OutcomeSuccess
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+
+	if recErr := s.audit.Record(entry); recErr != nil {
+		log.Printf("Failed to write audit log entry: %v", recErr)
+	}
+}
+
+// auditTargetFromRequest extracts the target of an action endpoint from its path parameters
+// and from the kind/name/namespace fields of its JSON body. Other scalar body fields,
+// query parameters and path parameters end up in the details.
+func auditTargetFromRequest(c echo.Context) (audit.Target, map[string]string) {
+	target := audit.Target{}
+	details := map[string]string{}
+
+	for i, name := range c.ParamNames() {
+		value := c.ParamValues()[i]
+		switch name {
+		case "context":
+		case "namespace":
+			target.Namespace = value
+		case "name", "podname", "nodename":
+			target.Name = value
+		default:
+			details[name] = value
+		}
+	}
+
+	for key, values := range c.QueryParams() {
+		if key == AuthTokenQueryParam || len(values) == 0 {
+			continue
+		}
+		details[key] = values[0]
+	}
+
+	if body := peekRequestBody(c.Request()); body != nil {
+		var fields map[string]interface{}
+		if json.Unmarshal(body, &fields) == nil {
+			for key, value := range fields {
+				str, ok := auditScalar(value)
+				if !ok {
+					continue
+				}
+				switch key {
+				case "kind":
+					target.Kind = str
+				case "name":
+					target.Name = str
+				case "namespace":
+					target.Namespace = str
+				default:
+					details[key] = str
+				}
+			}
+		}
+	}
+
+	if len(details) == 0 {
+		details = nil
+	}
+	return target, details
+}
+
+// auditScalar formats a decoded JSON scalar; objects and arrays are left out of the audit details
+func auditScalar(v interface{}) (string, bool) {
+	switch t := v.(type) {
+	case string:
+		return t, true
+	case bool:
+		return strconv.FormatBool(t), true
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64), true
+	default:
+		return "", false
+	}
+}
+
+// peekRequestBody reads the request body and puts it back so the handler can still bind it
+func peekRequestBody(req *http.Request) []byte {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+	body, err := io.ReadAll(io.LimitReader(req.Body, maxAuditBodySize))
+	if err != nil {
+		return nil
+	}
+	req.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), req.Body))
+	return body
+}
+
+// auditTargetFromAPIPath parses a Kubernetes API path such as
+// /apis/apps/v1/namespaces/default/deployments/web/scale into its target
+func auditTargetFromAPIPath(path string) audit.Target {
+	target := audit.Target{Path: path}
+
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	switch {
+	case len(segments) >= 2 && segments[0] == "api":
+		segments = segments[2:]
+	case len(segments) >= 3 && segments[0] == "apis":
+		segments = segments[3:]
+	default:
+		return target
+	}
+
+	if len(segments) >= 2 && segments[0] == "namespaces" {
+		if len(segments) == 2 {
+			// The namespace itself
+			target.Resource = "namespaces"
+			target.Name = segments[1]
+			return target
+		}
+		target.Namespace = segments[1]
+		segments = segments[2:]
+	}
+
+	if len(segments) >= 1 {
+		target.Resource = segments[0]
+	}
+	if len(segments) >= 2 {
+		target.Name = segments[1]
+	}
+	if len(segments) >= 3 {
+		target.Resource = fmt.Sprintf("%s/%s", segments[0], segments[2])
+	}
+	return target
+}
+
+// kubernetesVerb maps an HTTP method of the API proxy to the Kubernetes verb
+func kubernetesVerb(method, name string) string {
+	switch method {
+	case http.MethodPost:
+		return "create"
+	case http.MethodPut:
+		return "update"
+	case http.MethodPatch:
+		return "patch"
+	case http.MethodDelete:
+		if name == "" {
+			return "deletecollection"
+		}
+		return "delete"
+	default:
+		return strings.ToLower(method)
+	}
+}
+
+// handleAuditQuery serves GET /api/audit. Every audit.Filter field can be set through
+// the query parameter of the same name; since and until are RFC 3339 timestamps.
+func (s *Server) handleAuditQuery(c echo.Context) error {
+	filter := audit.Filter{
+		User:      c.QueryParam("user"),
+		Context:   c.QueryParam("context"),
+		Verb:      c.QueryParam("verb"),
+		Kind:      c.QueryParam("kind"),
+		Namespace: c.QueryParam("namespace"),
+		Name:      c.QueryParam("name"),
+		Outcome:   c.QueryParam("outcome"),
+		Limit:     500,
+	}
+
+	for param, dst := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if v := c.QueryParam(param); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{
+					"error": fmt.Sprintf("invalid %s, expected an RFC 3339 timestamp: %v", param, err),
+				})
+			}
+			*dst = t
+		}
+	}
+	if v := c.QueryParam("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": fmt.Sprintf("invalid limit: %s", v),
+			})
+		}
+		filter.Limit = limit
+	}
+
+	entries, err := s.audit.Query(filter)
+	if err != nil {
+		log.Printf("Failed to query audit log: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": fmt.Sprintf("failed to query audit log: %v", err),
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"enabled": s.audit != nil,
+		"entries": entries,
+	})
+}
@@ -0,0 +1,136 @@
+// Copyright 2025 Laszlo Consulting Kft.
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// AuthTokenQueryParam is the query parameter carrying the per-process session token
+	// in the URL that is opened in the browser on startup.
+	AuthTokenQueryParam = "token"
+
+	// authCookieName is the HttpOnly cookie the token is exchanged for
+	authCookieName = "capacitor_session"
+)
+
+// generateAuthToken returns a random, hex encoded 256 bit token
+func generateAuthToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate auth token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// AuthToken returns the per-process session token. Whoever holds it can use the server.
+func (s *Server) AuthToken() string {
+	return s.authToken
+}
+
+// validAuthToken compares the given token with the session token in constant time
+func (s *Server) validAuthToken(token string) bool {
+	if token == "" || s.authToken == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.authToken)) == 1
+}
+
+// isAuthenticated checks the session cookie, falling back to a bearer token for non-browser clients
+func (s *Server) isAuthenticated(r *http.Request) bool {
+	if cookie, err := r.Cookie(authCookieName); err == nil && s.validAuthToken(cookie.Value) {
+		return true
+	}
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return s.validAuthToken(strings.TrimSpace(token))
+	}
+	return false
+}
+
+// requiresAuth reports whether the request path belongs to the API, the Kubernetes proxy or the WebSockets
+func requiresAuth(path string) bool {
+	for _, prefix := range []string{"/api", "/k8s", "/ws"} {
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// authMiddleware exchanges the token from the startup URL for an HttpOnly cookie
+// and rejects API, proxy and WebSocket requests that don't carry a valid session.
+func (s *Server) authMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+
+		q := req.URL.Query()
+		if token := q.Get(AuthTokenQueryParam); token != "" {
+			if !s.validAuthToken(token) {
+				return c.JSON(http.StatusUnauthorized, map[string]string{
+					"error": "invalid session token",
+				})
+			}
+
+			c.SetCookie(&http.Cookie{
+				Name:     authCookieName,
+				Value:    s.authToken,
+				Path:     "/",
+				HttpOnly: true,
+				SameSite: http.SameSiteStrictMode,
+			})
+
+			// Redirect to the same URL without the token so it doesn't linger in the address bar or history
+			q.Del(AuthTokenQueryParam)
+			target := url.URL{Path: req.URL.Path, RawQuery: q.Encode()}
+			return c.Redirect(http.StatusFound, target.String())
+		}
+
+		if !requiresAuth(req.URL.Path) {
+			return next(c)
+		}
+
+		if !sameOrigin(req) {
+			return c.JSON(http.StatusForbidden, map[string]string{
+				"error": "cross-origin requests are not allowed",
+			})
+		}
+
+		if !s.isAuthenticated(req) {
+			return c.JSON(http.StatusUnauthorized, map[string]string{
+				"error": "missing or invalid session, open Capacitor using the URL printed on startup",
+			})
+		}
+
+		return next(c)
+	}
+}
+
+// sameOrigin reports whether the request comes from a page served by this server.
+// Requests without an Origin header (e.g. curl, same-origin GETs in some browsers) are allowed;
+// they still need a valid session. It is also used as the WebSocket upgraders' CheckOrigin.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+// origin returns the server's own origin, used to lock down CORS
+func (s *Server) origin() string {
+	return fmt.Sprintf("http://%s:%d", s.config.Address, s.config.Port)
+}
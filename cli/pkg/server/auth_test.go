@@ -0,0 +1,120 @@
+// Copyright 2025 Laszlo Consulting Kft.
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+const testAuthToken = "0123456789abcdef"
+
+func TestAuthMiddleware(t *testing.T) {
+	tests := []struct {
+		name         string
+		target       string
+		headers      map[string]string
+		cookie       string
+		wantStatus   int
+		wantLocation string
+		wantCookie   bool
+	}{
+		{
+			name:         "startup token is exchanged for a cookie",
+			target:       "/?" + AuthTokenQueryParam + "=" + testAuthToken,
+			wantStatus:   http.StatusFound,
+			wantLocation: "/",
+			wantCookie:   true,
+		},
+		{
+			name:         "redirect keeps the other parameters",
+			target:       "/flux?" + AuthTokenQueryParam + "=" + testAuthToken + "&namespace=apps",
+			wantStatus:   http.StatusFound,
+			wantLocation: "/flux?namespace=apps",
+			wantCookie:   true,
+		},
+		{name: "wrong startup token", target: "/?" + AuthTokenQueryParam + "=wrong", wantStatus: http.StatusUnauthorized},
+		{name: "api without a session", target: "/api/contexts", wantStatus: http.StatusUnauthorized},
+		{name: "proxy without a session", target: "/k8s/prod/api/v1/pods", wantStatus: http.StatusUnauthorized},
+		{name: "websocket without a session", target: "/ws", wantStatus: http.StatusUnauthorized},
+		{name: "wrong cookie", target: "/api/contexts", cookie: "wrong", wantStatus: http.StatusUnauthorized},
+		{
+			name:       "wrong bearer token",
+			target:     "/api/contexts",
+			headers:    map[string]string{"Authorization": "Bearer wrong"},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{name: "session cookie", target: "/api/contexts", cookie: testAuthToken, wantStatus: http.StatusOK},
+		{
+			name:       "bearer token",
+			target:     "/k8s/prod/api/v1/pods",
+			headers:    map[string]string{"Authorization": "Bearer " + testAuthToken},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "same origin",
+			target:     "/api/contexts",
+			headers:    map[string]string{"Origin": "http://example.com"},
+			cookie:     testAuthToken,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "cross origin, even with a session",
+			target:     "/api/contexts",
+			headers:    map[string]string{"Origin": "http://evil.example"},
+			cookie:     testAuthToken,
+			wantStatus: http.StatusForbidden,
+		},
+		{name: "static files are served without a session", target: "/assets/index.js", wantStatus: http.StatusOK},
+		{name: "index is served without a session", target: "/", wantStatus: http.StatusOK},
+		{name: "paths that only start like the api", target: "/apis-overview", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{authToken: testAuthToken}
+			handler := s.authMiddleware(func(c echo.Context) error {
+				return c.String(http.StatusOK, "ok")
+			})
+
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: authCookieName, Value: tt.cookie})
+			}
+			rec := httptest.NewRecorder()
+			if err := handler(echo.New().NewContext(req, rec)); err != nil {
+				t.Fatalf("authMiddleware() error = %v", err)
+			}
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if location := rec.Header().Get("Location"); location != tt.wantLocation {
+				t.Errorf("Location = %q, want %q", location, tt.wantLocation)
+			}
+
+			var session *http.Cookie
+			for _, cookie := range rec.Result().Cookies() {
+				if cookie.Name == authCookieName {
+					session = cookie
+				}
+			}
+			if !tt.wantCookie {
+				if session != nil {
+					t.Errorf("got a session cookie, want none")
+				}
+				return
+			}
+			if session == nil || session.Value != testAuthToken || !session.HttpOnly || session.SameSite != http.SameSiteStrictMode {
+				t.Errorf("session cookie = %+v, want an HttpOnly, SameSite=Strict cookie with the token", session)
+			}
+		})
+	}
+}
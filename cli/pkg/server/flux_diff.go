@@ -120,68 +120,14 @@ func FluxDiff(
 			diffSopsSecret(obj, liveObject, mergedObject, change)
 		}
 
-		if change.Action == ssa.UnchangedAction {
-			existingObject := &unstructured.Unstructured{}
-			existingObject.SetGroupVersionKind(obj.GroupVersionKind())
-			err = kubeClient.Get(ctx, client.ObjectKeyFromObject(obj), existingObject)
-			if err != nil {
-				return results, err
-			}
-			clusterYaml, err := renderToYAML(existingObject)
-			if err != nil {
-				return results, err
-			}
-
-			results = append(results, FluxDiffResult{
-				FileName:    change.Subject,
-				ClusterYaml: clusterYaml,
-				AppliedYaml: clusterYaml,
-				Created:     false,
-				HasChanges:  false,
-				Deleted:     false,
-			})
-		}
-
-		if change.Action == ssa.CreatedAction {
-			appliedYaml, err := renderToYAML(obj)
-			if err != nil {
-				// gather errors and continue, as we want to see all the diffs
-				diffErrs = append(diffErrs, err)
-				continue
-			}
-
-			results = append(results, FluxDiffResult{
-				FileName:    change.Subject,
-				ClusterYaml: "",
-				AppliedYaml: appliedYaml,
-				Created:     true,
-				HasChanges:  false,
-				Deleted:     false,
-			})
+		result, err := changeSetResult(ctx, kubeClient, obj, change, liveObject, mergedObject)
+		if err != nil {
+			// gather errors and continue, as we want to see all the diffs
+			diffErrs = append(diffErrs, err)
+			continue
 		}
-
-		if change.Action == ssa.ConfiguredAction {
-			clusterYaml, err := renderToYAML(liveObject)
-			if err != nil {
-				// gather errors and continue, as we want to see all the diffs
-				diffErrs = append(diffErrs, err)
-				continue
-			}
-			appliedYaml, err := renderToYAML(mergedObject)
-			if err != nil {
-				// gather errors and continue, as we want to see all the diffs
-				diffErrs = append(diffErrs, err)
-				continue
-			}
-
-			results = append(results, FluxDiffResult{
-				FileName:    change.Subject,
-				ClusterYaml: clusterYaml,
-				AppliedYaml: appliedYaml,
-				Created:     false,
-				HasChanges:  true,
-				Deleted:     false,
-			})
+		if result != nil {
+			results = append(results, *result)
 		}
 
 		addObjectsToInventory(newInventory, change)
@@ -221,6 +167,55 @@ func FluxDiff(
 	return results, errors.Reduce(errors.Flatten(errors.NewAggregate(diffErrs)))
 }
 
+// changeSetResult renders the outcome of a dry-run apply of obj as a diff result. Unchanged objects are
+// read from the cluster, so the result shows them like kubectl does. It returns nil for other actions, like skipped.
+func changeSetResult(ctx context.Context, kubeClient client.WithWatch, obj *unstructured.Unstructured, change *ssa.ChangeSetEntry, liveObject, mergedObject *unstructured.Unstructured) (*FluxDiffResult, error) {
+	switch change.Action {
+	case ssa.UnchangedAction:
+		existingObject := &unstructured.Unstructured{}
+		existingObject.SetGroupVersionKind(obj.GroupVersionKind())
+		if err := kubeClient.Get(ctx, client.ObjectKeyFromObject(obj), existingObject); err != nil {
+			return nil, err
+		}
+		clusterYaml, err := renderToYAML(existingObject)
+		if err != nil {
+			return nil, err
+		}
+		return &FluxDiffResult{
+			FileName:    change.Subject,
+			ClusterYaml: clusterYaml,
+			AppliedYaml: clusterYaml,
+		}, nil
+	case ssa.CreatedAction:
+		appliedYaml, err := renderToYAML(obj)
+		if err != nil {
+			return nil, err
+		}
+		return &FluxDiffResult{
+			FileName:    change.Subject,
+			AppliedYaml: appliedYaml,
+			Created:     true,
+		}, nil
+	case ssa.ConfiguredAction:
+		clusterYaml, err := renderToYAML(liveObject)
+		if err != nil {
+			return nil, err
+		}
+		appliedYaml, err := renderToYAML(mergedObject)
+		if err != nil {
+			return nil, err
+		}
+		return &FluxDiffResult{
+			FileName:    change.Subject,
+			ClusterYaml: clusterYaml,
+			AppliedYaml: appliedYaml,
+			HasChanges:  true,
+		}, nil
+	default:
+		return nil, nil
+	}
+}
+
 func renderToYAML(obj *unstructured.Unstructured) (string, error) {
 	yml, err := yaml.Marshal(obj.Object)
 	if err != nil {
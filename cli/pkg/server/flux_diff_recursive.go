@@ -0,0 +1,245 @@
+// Copyright 2025 Laszlo Consulting Kft.
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+	ssautil "github.com/fluxcd/pkg/ssa/utils"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"github.com/gimlet-io/capacitor/pkg/flux/build"
+	"github.com/gimlet-io/capacitor/pkg/flux/utils"
+	"github.com/gimlet-io/capacitor/pkg/kubernetes"
+)
+
+// KustomizationDiff is the diff of a single Kustomization in a recursive diff
+type KustomizationDiff struct {
+	Namespace  string           `json:"namespace"`
+	Name       string           `json:"name"`
+	Parent     string           `json:"parent,omitempty"` // namespace/name of the Kustomization that applies it, empty for the one diffed
+	Created    bool             `json:"created"`          // the parent creates the Kustomization, so all of its objects are new
+	Deleted    bool             `json:"deleted"`          // the parent prunes the Kustomization, and it prunes its objects if spec.prune is set
+	FluxResult []FluxDiffResult `json:"fluxResult"`
+	Error      string           `json:"error,omitempty"` // why this Kustomization couldn't be diffed, the others still are
+}
+
+// kustomizationDiffItem is a Kustomization waiting to be diffed in a recursive diff
+type kustomizationDiffItem struct {
+	kustomization *kustomizev1.Kustomization
+	parent        string
+	created       bool
+	deleted       bool
+}
+
+// DiffKustomizationRecursive diffs a Kustomization like DiffKustomization, then every Kustomization it applies,
+// recursively, each built from its own source artifact. Children are built from the spec their parent would apply.
+// The results are grouped per Kustomization, the named one first.
+func (s *Server) DiffKustomizationRecursive(ctx context.Context, client *kubernetes.Client, namespace, name string) ([]KustomizationDiff, error) {
+	root, err := s.getKustomization(ctx, client, name, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Kustomization %s/%s: %w", namespace, name, err)
+	}
+	log.Printf("Generating recursive FluxCD diff for Kustomization %s/%s", namespace, name)
+
+	// Source artifacts are downloaded once, Kustomizations of an app-of-apps often share them
+	sources := map[string]string{}
+	defer func() {
+		for _, dir := range sources {
+			os.RemoveAll(dir)
+		}
+	}()
+
+	configFlags, clientOpts := s.fluxClientConfig(client, namespace)
+	kubeClient, err := utils.KubeClient(configFlags, clientOpts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	diffs := []KustomizationDiff{}
+	visited := map[string]bool{}
+	queue := []kustomizationDiffItem{{kustomization: root}}
+	for len(queue) > 0 {
+		item := queue[0]
+		queue = queue[1:]
+		k := item.kustomization
+		key := k.Namespace + "/" + k.Name
+		if visited[key] {
+			continue
+		}
+		visited[key] = true
+
+		var results []FluxDiffResult
+		var err error
+		switch {
+		case item.deleted:
+			results, err = prunedKustomizationObjects(ctx, kubeClient, k)
+		case item.parent != "" && k.Spec.KubeConfig != nil:
+			err = fmt.Errorf("diff not supported for Kustomizations that apply to remote clusters")
+		default:
+			results, err = s.diffKustomizationItem(ctx, client, item, sources)
+		}
+		if err != nil && item.parent == "" {
+			return nil, err
+		}
+
+		diff := KustomizationDiff{
+			Namespace:  k.Namespace,
+			Name:       k.Name,
+			Parent:     item.parent,
+			Created:    item.created,
+			Deleted:    item.deleted,
+			FluxResult: []FluxDiffResult{},
+		}
+		if results != nil {
+			diff.FluxResult = results
+		}
+		if err != nil {
+			log.Printf("Error diffing Kustomization %s: %v", key, err)
+			diff.Error = err.Error()
+		}
+		diffs = append(diffs, diff)
+
+		queue = append(queue, childKustomizations(results, k)...)
+	}
+
+	return diffs, nil
+}
+
+// diffKustomizationItem builds and diffs a Kustomization of a recursive diff from its source artifact
+func (s *Server) diffKustomizationItem(ctx context.Context, client *kubernetes.Client, item kustomizationDiffItem, sources map[string]string) ([]FluxDiffResult, error) {
+	k := item.kustomization
+	sourceDir, err := s.kustomizationSourceDirectory(ctx, client, k, sources)
+	if err != nil {
+		return nil, err
+	}
+	if item.parent == "" {
+		return s.diffKustomizationSource(client, k, sourceDir)
+	}
+
+	// The builder takes the spec the parent applies from a file, and the inventory to prune from the cluster
+	if !item.created {
+		live, err := s.getKustomization(ctx, client, k.Name, k.Namespace)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get Kustomization %s/%s: %w", k.Namespace, k.Name, err)
+		}
+		k.Status = *live.Status.DeepCopy()
+	}
+	data, err := yaml.Marshal(k)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode Kustomization %s/%s: %w", k.Namespace, k.Name, err)
+	}
+	file, err := os.CreateTemp("", "flux-kustomization-*.yaml")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(file.Name())
+	_, err = file.Write(data)
+	file.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to write Kustomization %s/%s: %w", k.Namespace, k.Name, err)
+	}
+
+	// A Kustomization that doesn't exist yet can only be built as a dry-run
+	return s.diffKustomizationSource(client, k, sourceDir,
+		build.WithKustomizationFile(file.Name()),
+		build.WithDryRun(item.created),
+	)
+}
+
+// kustomizationSourceDirectory returns the extracted source artifact of a Kustomization,
+// downloading it only if no Kustomization in sources referenced the same source before
+func (s *Server) kustomizationSourceDirectory(ctx context.Context, client *kubernetes.Client, k *kustomizev1.Kustomization, sources map[string]string) (string, error) {
+	sourceRef := k.Spec.SourceRef.DeepCopy()
+	if sourceRef.Namespace == "" {
+		sourceRef.Namespace = k.Namespace
+	}
+	if dir, ok := sources[sourceRef.String()]; ok {
+		return dir, nil
+	}
+
+	dir, err := s.getSourceArtifactDirectory(ctx, client, k, sourceRef.Namespace)
+	if err != nil {
+		return "", fmt.Errorf("failed to get source artifact: %w", err)
+	}
+	sources[sourceRef.String()] = dir
+	return dir, nil
+}
+
+// childKustomizations returns the Kustomizations among the diff results of parent, as they would be applied,
+// or as they are in the cluster if parent prunes them
+func childKustomizations(results []FluxDiffResult, parent *kustomizev1.Kustomization) []kustomizationDiffItem {
+	var children []kustomizationDiffItem
+	for _, r := range results {
+		if !strings.HasPrefix(r.FileName, kustomizev1.KustomizationKind+"/") {
+			continue
+		}
+		doc := r.AppliedYaml
+		if r.Deleted {
+			doc = r.ClusterYaml
+		}
+
+		k := &kustomizev1.Kustomization{}
+		if err := yaml.Unmarshal([]byte(doc), k); err != nil {
+			log.Printf("Failed to parse Kustomization %s: %v", r.FileName, err)
+			continue
+		}
+		if !strings.HasPrefix(k.APIVersion, kustomizev1.GroupVersion.Group+"/") || k.Kind != kustomizev1.KustomizationKind {
+			continue
+		}
+		if k.Namespace == "" {
+			k.Namespace = parent.Namespace
+		}
+
+		children = append(children, kustomizationDiffItem{
+			kustomization: k,
+			parent:        parent.Namespace + "/" + parent.Name,
+			created:       r.Created,
+			deleted:       r.Deleted,
+		})
+	}
+	return children
+}
+
+// prunedKustomizationObjects returns the objects in the inventory of a pruned Kustomization as deleted diff entries,
+// as kustomize-controller garbage collects them when the Kustomization is deleted with spec.prune set
+func prunedKustomizationObjects(ctx context.Context, kubeClient client.WithWatch, k *kustomizev1.Kustomization) ([]FluxDiffResult, error) {
+	if !k.Spec.Prune || k.Status.Inventory == nil {
+		return nil, nil
+	}
+	objects, err := diffInventory(k.Status.Inventory, newInventory())
+	if err != nil {
+		return nil, err
+	}
+
+	var results []FluxDiffResult
+	for _, obj := range objects {
+		existingObject := &unstructured.Unstructured{}
+		existingObject.SetGroupVersionKind(obj.GroupVersionKind())
+		if err := kubeClient.Get(ctx, client.ObjectKeyFromObject(obj), existingObject); err != nil {
+			if client.IgnoreNotFound(err) == nil {
+				continue
+			}
+			return nil, err
+		}
+		clusterYaml, err := renderToYAML(existingObject)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, FluxDiffResult{
+			FileName:    ssautil.FmtUnstructured(obj),
+			ClusterYaml: clusterYaml,
+			Deleted:     true,
+		})
+	}
+	return results, nil
+}
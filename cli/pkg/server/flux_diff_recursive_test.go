@@ -0,0 +1,114 @@
+// Copyright 2025 Laszlo Consulting Kft.
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"fmt"
+	"testing"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func kustomizationYaml(namespace, name, path string) string {
+	doc := "apiVersion: kustomize.toolkit.fluxcd.io/v1\nkind: Kustomization\nmetadata:\n  name: " + name + "\n"
+	if namespace != "" {
+		doc += "  namespace: " + namespace + "\n"
+	}
+	return doc + "spec:\n  path: " + path + "\n  sourceRef:\n    kind: GitRepository\n    name: infra\n"
+}
+
+// formatChildren formats diff items as "namespace/name path parent state"
+func formatChildren(items []kustomizationDiffItem) []string {
+	var out []string
+	for _, item := range items {
+		state := "existing"
+		if item.created {
+			state = "created"
+		} else if item.deleted {
+			state = "deleted"
+		}
+		out = append(out, fmt.Sprintf("%s/%s %s %s %s", item.kustomization.Namespace, item.kustomization.Name, item.kustomization.Spec.Path, item.parent, state))
+	}
+	return out
+}
+
+func TestChildKustomizations(t *testing.T) {
+	parent := &kustomizev1.Kustomization{ObjectMeta: metav1.ObjectMeta{Namespace: "flux-system", Name: "infra"}}
+
+	tests := []struct {
+		name    string
+		results []FluxDiffResult
+		want    []string
+	}{
+		{
+			name: "created child in the parent's namespace",
+			results: []FluxDiffResult{{
+				FileName:    "Kustomization/flux-system/apps",
+				AppliedYaml: kustomizationYaml("", "apps", "./apps"),
+				Created:     true,
+			}},
+			want: []string{"flux-system/apps ./apps flux-system/infra created"},
+		},
+		{
+			name: "changed child is diffed with the applied spec",
+			results: []FluxDiffResult{{
+				FileName:    "Kustomization/apps/web",
+				ClusterYaml: kustomizationYaml("apps", "web", "./web/v1"),
+				AppliedYaml: kustomizationYaml("apps", "web", "./web/v2"),
+				HasChanges:  true,
+			}},
+			want: []string{"apps/web ./web/v2 flux-system/infra existing"},
+		},
+		{
+			name: "unchanged child",
+			results: []FluxDiffResult{{
+				FileName:    "Kustomization/apps/web",
+				ClusterYaml: kustomizationYaml("apps", "web", "./web"),
+				AppliedYaml: kustomizationYaml("apps", "web", "./web"),
+			}},
+			want: []string{"apps/web ./web flux-system/infra existing"},
+		},
+		{
+			name: "pruned child is diffed with the cluster spec",
+			results: []FluxDiffResult{{
+				FileName:    "Kustomization/apps/legacy",
+				ClusterYaml: kustomizationYaml("apps", "legacy", "./legacy"),
+				Deleted:     true,
+			}},
+			want: []string{"apps/legacy ./legacy flux-system/infra deleted"},
+		},
+		{
+			name: "other objects are skipped",
+			results: []FluxDiffResult{
+				{FileName: "Deployment/apps/web", AppliedYaml: "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\n"},
+				{FileName: "ConfigMap/apps/kustomization", AppliedYaml: kustomizationYaml("apps", "kustomization", "./x")},
+			},
+		},
+		{
+			name: "kustomize's own Kustomization kind is skipped",
+			results: []FluxDiffResult{{
+				FileName:    "Kustomization/apps/overlay",
+				AppliedYaml: "apiVersion: kustomize.config.k8s.io/v1beta1\nkind: Kustomization\nmetadata:\n  name: overlay\n",
+			}},
+		},
+		{
+			name: "unparsable Kustomization is skipped",
+			results: []FluxDiffResult{
+				{FileName: "Kustomization/apps/broken", AppliedYaml: "spec: [\n"},
+				{FileName: "Kustomization/apps/web", AppliedYaml: kustomizationYaml("apps", "web", "./web"), Created: true},
+			},
+			want: []string{"apps/web ./web flux-system/infra created"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := formatChildren(childKustomizations(tt.results, parent))
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("childKustomizations() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
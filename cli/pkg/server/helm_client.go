@@ -0,0 +1,141 @@
+// Copyright 2025 Laszlo Consulting Kft.
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gimlet-io/capacitor/pkg/config"
+	"github.com/gimlet-io/capacitor/pkg/helm"
+	"github.com/gimlet-io/capacitor/pkg/kubernetes"
+)
+
+// crdWatchPath is watched to invalidate the Helm discovery cache when CRDs come and go
+const crdWatchPath = "/apis/apiextensions.k8s.io/v1/customresourcedefinitions"
+
+// HelmClient returns the Helm client of the proxy's context. It is created on first use
+// and shared by every request, so action configurations and API discovery are cached.
+func (p *KubernetesProxy) HelmClient() (*helm.Client, error) {
+	p.helmClientOnce.Do(func() {
+		hc, err := helm.NewClient(p.k8sClient.Config, "", p.helmStorage)
+		if err != nil {
+			p.helmClientErr = fmt.Errorf("failed to create helm client: %w", err)
+			return
+		}
+		p.helmClient = hc
+		go invalidateHelmDiscoveryOnCRDChanges(p.k8sClient, hc)
+	})
+	return p.helmClient, p.helmClientErr
+}
+
+// helmJobOptions are the options of Helm actions that run as jobs, as sent in the request body
+type helmJobOptions struct {
+	Force         bool   `json:"force"`
+	CleanupOnFail bool   `json:"cleanupOnFail"`
+	NoHooks       bool   `json:"noHooks"`
+	Timeout       string `json:"timeout"` // Go duration, e.g. 10m
+}
+
+// timeout parses the timeout option, zero means the action's default
+func (o helmJobOptions) timeout() (time.Duration, error) {
+	if o.Timeout == "" {
+		return 0, nil
+	}
+	timeout, err := time.ParseDuration(o.Timeout)
+	if err != nil || timeout <= 0 {
+		return 0, fmt.Errorf("invalid timeout: %s", o.Timeout)
+	}
+	return timeout, nil
+}
+
+// helmStorageFor returns where Helm keeps the releases of a context, according to the configuration
+func helmStorageFor(cfg *config.Config, contextName string) helm.Storage {
+	return helm.Storage{
+		Driver:              cfg.HelmDriverFor(contextName),
+		SQLConnectionString: cfg.HelmSQLConnectionString,
+	}
+}
+
+// invalidateHelmDiscoveryOnCRDChanges watches CustomResourceDefinitions for the lifetime of the process
+// and invalidates the Helm client's discovery cache whenever the set of served group versions changes.
+// Status updates of CRDs don't change the set, so they don't throw the cache away.
+func invalidateHelmDiscoveryOnCRDChanges(client *kubernetes.Client, hc *helm.Client) {
+	events := make(chan *kubernetes.WatchEvent, 100)
+	go func() {
+		defer close(events)
+		if err := client.WatchPath(context.Background(), crdWatchPath, events); err != nil {
+			log.Printf("Stopped watching CRDs, Helm discovery cache won't follow CRD changes [context=%s]: %v",
+				client.CurrentContext, err)
+		}
+	}()
+
+	served := map[string]string{} // CRD name -> served group versions
+	for event := range events {
+		changed := false
+		switch event.Type {
+		case kubernetes.SyncedEventType:
+			var synced kubernetes.WatchSync
+			if json.Unmarshal(event.Object, &synced) != nil {
+				continue
+			}
+			listed := map[string]bool{}
+			for _, name := range synced.Objects {
+				listed[name] = true
+			}
+			for name := range served {
+				if !listed[name] {
+					delete(served, name)
+					changed = true
+				}
+			}
+		case "ADDED", "MODIFIED":
+			name, versions, ok := crdServedVersions(event.Object)
+			if ok && served[name] != versions {
+				served[name] = versions
+				changed = true
+			}
+		case "DELETED":
+			if name, _, ok := crdServedVersions(event.Object); ok {
+				delete(served, name)
+				changed = true
+			}
+		}
+		if changed {
+			hc.InvalidateDiscovery()
+		}
+	}
+}
+
+// crdServedVersions returns the name of a CRD and its served group versions in a comparable form
+func crdServedVersions(raw json.RawMessage) (string, string, bool) {
+	var crd struct {
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+		Spec struct {
+			Group    string `json:"group"`
+			Versions []struct {
+				Name   string `json:"name"`
+				Served bool   `json:"served"`
+			} `json:"versions"`
+		} `json:"spec"`
+	}
+	if err := json.Unmarshal(raw, &crd); err != nil || crd.Metadata.Name == "" {
+		return "", "", false
+	}
+	var versions []string
+	for _, v := range crd.Spec.Versions {
+		if v.Served {
+			versions = append(versions, crd.Spec.Group+"/"+v.Name)
+		}
+	}
+	sort.Strings(versions)
+	return crd.Metadata.Name, strings.Join(versions, ","), true
+}
@@ -0,0 +1,121 @@
+// Copyright 2025 Laszlo Consulting Kft.
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fluxcd/cli-utils/pkg/kstatus/polling"
+	"github.com/fluxcd/pkg/ssa"
+	"github.com/fluxcd/pkg/ssa/normalize"
+	ssautil "github.com/fluxcd/pkg/ssa/utils"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gimlet-io/capacitor/pkg/flux/utils"
+	"github.com/gimlet-io/capacitor/pkg/helm"
+	"github.com/gimlet-io/capacitor/pkg/kubernetes"
+)
+
+// The field manager dry-run applies of Helm objects use. Helm 3 writes objects with client-side
+// three-way merge patches under this name, it doesn't own fields the way a server-side apply does.
+const (
+	helmFieldManager = "helm"
+	helmFieldGroup   = "helm.sh"
+)
+
+// HelmDrift performs a server-side dry-run apply of every object of a Helm release manifest and compares
+// the result to the live objects, to find changes made out-of-band, e.g. with kubectl.
+// Objects of the manifest that are missing from the cluster are reported as created.
+//
+// Helm 3 uses client-side apply, so this is an approximation: the dry-run takes ownership of every field
+// of the manifest as the field manager "helm", and reports changes to fields another manager set,
+// and to field ownership, that `helm upgrade` wouldn't make. Fields added out-of-band
+// that the manifest doesn't set are not reported.
+func HelmDrift(kubeClient client.WithWatch, manifest, namespace string) ([]FluxDiffResult, error) {
+	objects, err := helmManifestObjects(kubeClient, manifest, namespace)
+	if err != nil {
+		return []FluxDiffResult{}, err
+	}
+	return diffHelmObjects(kubeClient, objects, ssa.Owner{
+		Field: helmFieldManager,
+		Group: helmFieldGroup,
+	})
+}
+
+// helmManifestObjects parses a rendered Helm manifest into normalized objects, sorted in apply order.
+// Helm installs namespaced objects without a namespace into the release namespace.
+func helmManifestObjects(kubeClient client.WithWatch, manifest, namespace string) ([]*unstructured.Unstructured, error) {
+	objects, err := ssautil.ReadObjects(strings.NewReader(manifest))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse release manifest: %w", err)
+	}
+	for _, obj := range objects {
+		// Kinds the cluster doesn't know are left alone, their diff fails with a clear error
+		if obj.GetNamespace() == "" {
+			if namespaced, err := kubeClient.IsObjectNamespaced(obj); err == nil && namespaced {
+				obj.SetNamespace(namespace)
+			}
+		}
+	}
+	sort.Sort(ssa.SortableUnstructureds(objects))
+
+	if err := normalize.UnstructuredList(objects); err != nil {
+		return nil, err
+	}
+	return objects, nil
+}
+
+// diffHelmObjects dry-run applies objects as owner and compares them to the cluster
+func diffHelmObjects(kubeClient client.WithWatch, objects []*unstructured.Unstructured, owner ssa.Owner) ([]FluxDiffResult, error) {
+	results := []FluxDiffResult{}
+
+	statusPoller := polling.NewStatusPoller(kubeClient, kubeClient.RESTMapper(), polling.Options{})
+	resourceManager := ssa.NewResourceManager(kubeClient, statusPoller, owner)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var diffErrs []error
+	for _, obj := range objects {
+		change, liveObject, mergedObject, err := resourceManager.Diff(ctx, obj, ssa.DiffOptions{})
+		if err != nil {
+			// gather errors and continue, as we want to see all the diffs
+			diffErrs = append(diffErrs, err)
+			continue
+		}
+
+		result, err := changeSetResult(ctx, kubeClient, obj, change, liveObject, mergedObject)
+		if err != nil {
+			diffErrs = append(diffErrs, err)
+			continue
+		}
+		if result != nil {
+			results = append(results, *result)
+		}
+	}
+
+	return results, errors.Reduce(errors.Flatten(errors.NewAggregate(diffErrs)))
+}
+
+// diffHelmRelease compares the objects of a Helm release revision to the cluster, 0 means the latest revision
+func (s *Server) diffHelmRelease(ctx context.Context, client *kubernetes.Client, hc *helm.Client, name, namespace string, revision int) ([]FluxDiffResult, error) {
+	manifest, err := hc.GetManifest(ctx, name, namespace, revision)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get release manifest: %w", err)
+	}
+
+	configFlags, clientOpts := s.fluxClientConfig(client, namespace)
+	kubeClient, err := utils.KubeClient(configFlags, clientOpts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	return HelmDrift(kubeClient, manifest, namespace)
+}
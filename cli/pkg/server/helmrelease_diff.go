@@ -0,0 +1,222 @@
+// Copyright 2025 Laszlo Consulting Kft.
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	helmv2 "github.com/fluxcd/helm-controller/api/v2"
+	"github.com/fluxcd/pkg/ssa"
+	ssautil "github.com/fluxcd/pkg/ssa/utils"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gimlet-io/capacitor/pkg/flux/utils"
+	"github.com/gimlet-io/capacitor/pkg/helm"
+	"github.com/gimlet-io/capacitor/pkg/kubernetes"
+)
+
+// helm-controller applies release objects under this field manager and labels them with their HelmRelease
+const (
+	helmControllerFieldManager = "helm-controller"
+	helmControllerGroup        = "helm.toolkit.fluxcd.io"
+	helmReleaseNameLabel       = helmControllerGroup + "/name"
+	helmReleaseNamespaceLabel  = helmControllerGroup + "/namespace"
+)
+
+// DiffHelmRelease loads the named Flux HelmRelease from the cluster, renders the chart artifact of its source
+// with its values and diffs every object against the cluster, like DiffKustomization does for Kustomizations.
+// Post-renderers are not applied, so the objects they patch show up as changed.
+func (s *Server) DiffHelmRelease(ctx context.Context, client *kubernetes.Client, hc *helm.Client, namespace, name string) ([]FluxDiffResult, error) {
+	hr, err := s.getHelmRelease(ctx, client, name, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HelmRelease %s/%s: %w", namespace, name, err)
+	}
+	log.Printf("Generating FluxCD diff for HelmRelease %s/%s", hr.Namespace, hr.Name)
+	if len(hr.Spec.PostRenderers) > 0 {
+		log.Printf("HelmRelease %s/%s has post-renderers, they are not applied to the diff", hr.Namespace, hr.Name)
+	}
+
+	chartDir, err := s.getHelmReleaseChartDirectory(ctx, client, hr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chart artifact: %w", err)
+	}
+	defer os.RemoveAll(chartDir)
+
+	values, _, err := helmReleaseValues(ctx, client.Clientset.CoreV1(), hr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compose values: %w", err)
+	}
+
+	targetNamespace := hr.GetReleaseNamespace()
+	rendered, err := hc.RenderRelease(ctx, chartDir, hr.GetReleaseName(), hr.GetStorageNamespace(), targetNamespace, values)
+	if err != nil {
+		return nil, err
+	}
+
+	configFlags, clientOpts := s.fluxClientConfig(client, targetNamespace)
+	kubeClient, err := utils.KubeClient(configFlags, clientOpts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	objects, err := helmManifestObjects(kubeClient, rendered.Manifest, targetNamespace)
+	if err != nil {
+		return nil, err
+	}
+	for _, obj := range objects {
+		setHelmReleaseMetadata(obj, hr)
+	}
+
+	results, diffErr := diffHelmObjects(kubeClient, objects, ssa.Owner{
+		Field: helmControllerFieldManager,
+		Group: helmControllerGroup,
+	})
+	if diffErr != nil || rendered.CurrentManifest == "" {
+		return results, diffErr
+	}
+
+	// Objects of the current release that the new one no longer has are deleted by the upgrade
+	deleted, err := removedHelmObjects(ctx, kubeClient, rendered.CurrentManifest, targetNamespace, objects)
+	if err != nil {
+		return results, err
+	}
+	return append(results, deleted...), nil
+}
+
+// getHelmRelease fetches a Flux HelmRelease and decodes it into the typed API object
+func (s *Server) getHelmRelease(ctx context.Context, client *kubernetes.Client, name, namespace string) (*helmv2.HelmRelease, error) {
+	apiPath, err := s.discoverFluxAPIPathForClient(ctx, client, "HelmRelease")
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover HelmRelease API path: %w", err)
+	}
+	path := fmt.Sprintf(apiPath, namespace, name)
+	data, err := client.Clientset.RESTClient().Get().AbsPath(path).DoRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var hr helmv2.HelmRelease
+	if err := json.Unmarshal(data, &hr); err != nil {
+		return nil, fmt.Errorf("failed to parse HelmRelease resource: %w", err)
+	}
+	return &hr, nil
+}
+
+// getHelmReleaseChartDirectory downloads and extracts the chart artifact of a HelmRelease: the one of its chartRef,
+// an OCIRepository or HelmChart, or the one of the HelmChart helm-controller created from its chart template
+func (s *Server) getHelmReleaseChartDirectory(ctx context.Context, client *kubernetes.Client, hr *helmv2.HelmRelease) (string, error) {
+	var sourceResource map[string]interface{}
+	var err error
+
+	switch {
+	case hr.HasChartRef():
+		ref := hr.Spec.ChartRef
+		sourceNamespace := hr.Namespace
+		if ref.Namespace != "" {
+			sourceNamespace = ref.Namespace
+		}
+		switch strings.ToLower(ref.Kind) {
+		case "ocirepository":
+			sourceResource, err = s.getOCIRepository(ctx, client, ref.Name, sourceNamespace)
+		case "helmchart":
+			sourceResource, err = s.getHelmChart(ctx, client, ref.Name, sourceNamespace)
+		default:
+			return "", fmt.Errorf("unsupported chartRef kind: %s", ref.Kind)
+		}
+	case hr.HasChartTemplate():
+		chartNamespace, chartName := hr.Namespace, hr.GetHelmChartName()
+		if hr.Spec.Chart.Spec.SourceRef.Namespace != "" {
+			chartNamespace = hr.Spec.Chart.Spec.SourceRef.Namespace
+		}
+		if ns, n, ok := strings.Cut(hr.Status.HelmChart, "/"); ok {
+			chartNamespace, chartName = ns, n
+		}
+		sourceResource, err = s.getHelmChart(ctx, client, chartName, chartNamespace)
+	default:
+		return "", fmt.Errorf("HelmRelease has neither chart nor chartRef")
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get chart source: %w", err)
+	}
+
+	artifactURL, err := sourceArtifactURL(sourceResource)
+	if err != nil {
+		return "", err
+	}
+	return DownloadAndExtractArtifact(ctx, client, artifactURL)
+}
+
+// setHelmReleaseMetadata adds what helm-controller's post-renderers add to every object:
+// the common metadata of the HelmRelease and the labels that point back to it
+func setHelmReleaseMetadata(obj *unstructured.Unstructured, hr *helmv2.HelmRelease) {
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	if hr.Spec.CommonMetadata != nil {
+		for k, v := range hr.Spec.CommonMetadata.Labels {
+			labels[k] = v
+		}
+		if len(hr.Spec.CommonMetadata.Annotations) > 0 {
+			annotations := obj.GetAnnotations()
+			if annotations == nil {
+				annotations = map[string]string{}
+			}
+			for k, v := range hr.Spec.CommonMetadata.Annotations {
+				annotations[k] = v
+			}
+			obj.SetAnnotations(annotations)
+		}
+	}
+	labels[helmReleaseNameLabel] = hr.Name
+	labels[helmReleaseNamespaceLabel] = hr.Namespace
+	obj.SetLabels(labels)
+}
+
+// removedHelmObjects returns the objects of the current release manifest that are not among the rendered objects
+// and still exist in the cluster, as deleted diff entries
+func removedHelmObjects(ctx context.Context, kubeClient client.WithWatch, currentManifest, namespace string, rendered []*unstructured.Unstructured) ([]FluxDiffResult, error) {
+	current, err := helmManifestObjects(kubeClient, currentManifest, namespace)
+	if err != nil {
+		return nil, err
+	}
+	keep := make(map[string]bool, len(rendered))
+	for _, obj := range rendered {
+		keep[ssautil.FmtUnstructured(obj)] = true
+	}
+
+	var results []FluxDiffResult
+	for _, obj := range current {
+		// Helm leaves objects with the keep resource policy in place
+		policy := obj.GetAnnotations()["helm.sh/resource-policy"]
+		if keep[ssautil.FmtUnstructured(obj)] || strings.ToLower(strings.TrimSpace(policy)) == "keep" {
+			continue
+		}
+		existingObject := &unstructured.Unstructured{}
+		existingObject.SetGroupVersionKind(obj.GroupVersionKind())
+		if err := kubeClient.Get(ctx, client.ObjectKeyFromObject(obj), existingObject); err != nil {
+			if client.IgnoreNotFound(err) == nil {
+				continue
+			}
+			return nil, err
+		}
+		clusterYaml, err := renderToYAML(existingObject)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, FluxDiffResult{
+			FileName:    ssautil.FmtUnstructured(obj),
+			ClusterYaml: clusterYaml,
+			Deleted:     true,
+		})
+	}
+	return results, nil
+}
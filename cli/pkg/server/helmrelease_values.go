@@ -0,0 +1,328 @@
+// Copyright 2025 Laszlo Consulting Kft.
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	helmv2 "github.com/fluxcd/helm-controller/api/v2"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/strvals"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/gimlet-io/capacitor/pkg/helm"
+	"github.com/gimlet-io/capacitor/pkg/kubernetes"
+)
+
+// defaultValuesKey is the key of a valuesFrom ConfigMap or Secret that holds the values when valuesKey isn't set
+const defaultValuesKey = "values.yaml"
+
+// Value leaf statuses, comparing the effective values of a HelmRelease to the values of the deployed release
+const (
+	valueUnchanged = "unchanged"
+	valueChanged   = "changed"
+	valueAdded     = "added"   // in the effective values, not deployed yet
+	valueRemoved   = "removed" // deployed, no longer in the effective values
+)
+
+// ValueSource is where a value of a HelmRelease comes from: spec.values of the HelmRelease,
+// or a valuesFrom ConfigMap or Secret
+type ValueSource struct {
+	Kind       string `json:"kind"` // HelmRelease, ConfigMap or Secret
+	Name       string `json:"name"`
+	Key        string `json:"key"` // spec.values, or the valuesKey of the ConfigMap or Secret
+	TargetPath string `json:"targetPath,omitempty"`
+}
+
+// ValueLeaf is a single value of a HelmRelease, with its source and the value the deployed release has.
+// Lists are leaves, as Helm replaces them as a whole.
+type ValueLeaf struct {
+	Path     []string     `json:"path"`
+	Value    interface{}  `json:"value,omitempty"`
+	Source   *ValueSource `json:"source,omitempty"` // not set for removed leaves
+	Deployed interface{}  `json:"deployed,omitempty"`
+	Status   string       `json:"status,omitempty"` // unchanged, changed, added or removed; not set if the release couldn't be read
+}
+
+// HelmReleaseValues are the effective values of a HelmRelease, as helm-controller passes them to Helm,
+// compared to the user-supplied values of the deployed release
+type HelmReleaseValues struct {
+	ReleaseName      string                 `json:"releaseName"`
+	StorageNamespace string                 `json:"storageNamespace"`
+	Values           map[string]interface{} `json:"values"`
+	Deployed         map[string]interface{} `json:"deployed,omitempty"`
+	DeployedError    string                 `json:"deployedError,omitempty"` // why the deployed values couldn't be read, e.g. the release isn't installed
+	Leaves           []ValueLeaf            `json:"leaves"`
+}
+
+// valueOrigins maps the path of every leaf of composed values to its source
+type valueOrigins map[string]ValueSource
+
+// pathSeparator joins the keys of a value path into an origins key, keys can contain dots
+const pathSeparator = "\x00"
+
+// HelmReleaseEffectiveValues composes the values of a HelmRelease the way helm-controller does, annotates every leaf
+// with its source and compares them to the values of the deployed release
+func (s *Server) HelmReleaseEffectiveValues(ctx context.Context, client *kubernetes.Client, hc *helm.Client, namespace, name string) (*HelmReleaseValues, error) {
+	hr, err := s.getHelmRelease(ctx, client, name, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HelmRelease %s/%s: %w", namespace, name, err)
+	}
+	values, origins, err := helmReleaseValues(ctx, client.Clientset.CoreV1(), hr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compose values: %w", err)
+	}
+	// The deployed values went through JSON, so numbers compare as float64
+	values, err = jsonRoundTrip(values)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &HelmReleaseValues{
+		ReleaseName:      hr.GetReleaseName(),
+		StorageNamespace: hr.GetStorageNamespace(),
+		Values:           values,
+	}
+	deployed, err := hc.GetValues(ctx, result.ReleaseName, result.StorageNamespace, false, 0)
+	if err != nil {
+		result.DeployedError = err.Error()
+	} else {
+		if deployed == nil {
+			deployed = map[string]interface{}{}
+		}
+		result.Deployed = deployed
+	}
+
+	result.Leaves = compareValueLeaves(values, result.Deployed, origins)
+	return result, nil
+}
+
+// compareValueLeaves lists the leaves of the effective values with their origin, and compares them to the leaves
+// of the deployed values. Leaves only deployed are removed ones. A nil deployed means the release couldn't be read,
+// the leaves have no status then.
+func compareValueLeaves(values, deployed map[string]interface{}, origins valueOrigins) []ValueLeaf {
+	var leaves []ValueLeaf
+	effectiveLeaves := valueLeaves(values)
+	deployedLeaves := valueLeaves(deployed)
+	for key, value := range effectiveLeaves {
+		leaf := ValueLeaf{Path: strings.Split(key, pathSeparator), Value: value}
+		if source, ok := origins[key]; ok {
+			leaf.Source = &source
+		}
+		if deployed != nil {
+			deployedValue, ok := deployedLeaves[key]
+			leaf.Deployed = deployedValue
+			switch {
+			case !ok:
+				leaf.Status = valueAdded
+			case reflect.DeepEqual(value, deployedValue):
+				leaf.Status = valueUnchanged
+			default:
+				leaf.Status = valueChanged
+			}
+		}
+		leaves = append(leaves, leaf)
+	}
+	for key, deployedValue := range deployedLeaves {
+		if _, ok := effectiveLeaves[key]; ok {
+			continue
+		}
+		leaves = append(leaves, ValueLeaf{
+			Path:     strings.Split(key, pathSeparator),
+			Deployed: deployedValue,
+			Status:   valueRemoved,
+		})
+	}
+	sort.Slice(leaves, func(i, j int) bool {
+		return strings.Join(leaves[i].Path, pathSeparator) < strings.Join(leaves[j].Path, pathSeparator)
+	})
+	if leaves == nil {
+		leaves = []ValueLeaf{}
+	}
+	return leaves
+}
+
+// helmReleaseValues composes the values of a HelmRelease the way helm-controller does: the valuesFrom
+// references are merged in order, a reference with a targetPath sets the single value at that path,
+// and spec.values is merged on top. Optional references that don't exist are skipped.
+// It also returns the source of every leaf of the values.
+func helmReleaseValues(ctx context.Context, core corev1client.CoreV1Interface, hr *helmv2.HelmRelease) (map[string]interface{}, valueOrigins, error) {
+	result := map[string]interface{}{}
+	origins := valueOrigins{}
+	configMaps := map[string]map[string]string{}
+	secrets := map[string]map[string][]byte{}
+
+	for _, ref := range hr.Spec.ValuesFrom {
+		key := ref.ValuesKey
+		if key == "" {
+			key = defaultValuesKey
+		}
+
+		var data []byte
+		var found bool
+		switch ref.Kind {
+		case "ConfigMap":
+			cm, ok := configMaps[ref.Name]
+			if !ok {
+				obj, err := core.ConfigMaps(hr.Namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+				if err != nil && !apierrors.IsNotFound(err) {
+					return nil, nil, fmt.Errorf("failed to get values from ConfigMap %s/%s: %w", hr.Namespace, ref.Name, err)
+				}
+				if err == nil {
+					cm = obj.Data
+					configMaps[ref.Name] = cm
+				}
+			}
+			if cm != nil {
+				var value string
+				value, found = cm[key]
+				data = []byte(value)
+			}
+		case "Secret":
+			secret, ok := secrets[ref.Name]
+			if !ok {
+				obj, err := core.Secrets(hr.Namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+				if err != nil && !apierrors.IsNotFound(err) {
+					return nil, nil, fmt.Errorf("failed to get values from Secret %s/%s: %w", hr.Namespace, ref.Name, err)
+				}
+				if err == nil {
+					secret = obj.Data
+					secrets[ref.Name] = secret
+				}
+			}
+			if secret != nil {
+				data, found = secret[key]
+			}
+		default:
+			return nil, nil, fmt.Errorf("unsupported values reference kind %s", ref.Kind)
+		}
+
+		if !found {
+			if ref.Optional {
+				continue
+			}
+			return nil, nil, fmt.Errorf("values key %s of %s %s/%s not found", key, ref.Kind, hr.Namespace, ref.Name)
+		}
+		source := ValueSource{Kind: ref.Kind, Name: ref.Name, Key: key, TargetPath: ref.TargetPath}
+
+		if ref.TargetPath != "" {
+			if err := replacePathValue(result, ref.TargetPath, string(data)); err != nil {
+				return nil, nil, fmt.Errorf("invalid target path %s of %s %s/%s: %w", ref.TargetPath, ref.Kind, hr.Namespace, ref.Name, err)
+			}
+			// The same path parsed on its own tells which leaf was set
+			single := map[string]interface{}{}
+			if err := replacePathValue(single, ref.TargetPath, string(data)); err == nil {
+				for leaf := range valueLeaves(single) {
+					origins.set(leaf, nil, source)
+				}
+			}
+			continue
+		}
+
+		values, err := chartutil.ReadValues(data)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse values key %s of %s %s/%s: %w", key, ref.Kind, hr.Namespace, ref.Name, err)
+		}
+		result = mergeValues(result, values, nil, origins, source)
+	}
+
+	specValues := ValueSource{Kind: helmv2.HelmReleaseKind, Name: hr.Name, Key: "spec.values"}
+	return mergeValues(result, hr.GetValues(), nil, origins, specValues), origins, nil
+}
+
+// replacePathValue sets the value at a dot notation path with Helm's --set parser, like helm-controller does.
+// Quoted values are set as strings, others are typed like with --set.
+func replacePathValue(values map[string]interface{}, path, value string) error {
+	const (
+		singleQuote = "'"
+		doubleQuote = `"`
+	)
+	isSingleQuoted := strings.HasPrefix(value, singleQuote) && strings.HasSuffix(value, singleQuote)
+	isDoubleQuoted := strings.HasPrefix(value, doubleQuote) && strings.HasSuffix(value, doubleQuote)
+	if isSingleQuoted || isDoubleQuoted {
+		value = strings.Trim(value, singleQuote+doubleQuote)
+		return strvals.ParseIntoString(path+"="+value, values)
+	}
+	return strvals.ParseInto(path+"="+value, values)
+}
+
+// mergeValues merges b into a copy of a, maps are merged recursively and anything else in b replaces.
+// The leaves b sets, under path, are recorded in origins as coming from source.
+func mergeValues(a, b map[string]interface{}, path []string, origins valueOrigins, source ValueSource) map[string]interface{} {
+	out := make(map[string]interface{}, len(a))
+	for k, v := range a {
+		out[k] = v
+	}
+	for k, v := range b {
+		keyPath := append(append([]string{}, path...), k)
+		if v, ok := v.(map[string]interface{}); ok {
+			if bv, ok := out[k]; ok {
+				if bv, ok := bv.(map[string]interface{}); ok {
+					out[k] = mergeValues(bv, v, keyPath, origins, source)
+					continue
+				}
+			}
+		}
+		out[k] = v
+		origins.set(strings.Join(keyPath, pathSeparator), v, source)
+	}
+	return out
+}
+
+// set records source as the origin of the value at key, replacing the origins of what was there.
+// A nil value marks key itself as the leaf.
+func (o valueOrigins) set(key string, value interface{}, source ValueSource) {
+	for existing := range o {
+		if existing == key || strings.HasPrefix(existing, key+pathSeparator) {
+			delete(o, existing)
+		}
+	}
+	if m, isMap := value.(map[string]interface{}); isMap && len(m) > 0 {
+		for leaf := range valueLeaves(m) {
+			o[key+pathSeparator+leaf] = source
+		}
+		return
+	}
+	o[key] = source
+}
+
+// valueLeaves flattens values into their leaves keyed by path. Empty maps and anything that isn't a map are leaves.
+func valueLeaves(values interface{}) map[string]interface{} {
+	leaves := map[string]interface{}{}
+	m, ok := values.(map[string]interface{})
+	if !ok {
+		return leaves
+	}
+	for k, v := range m {
+		child, isMap := v.(map[string]interface{})
+		if !isMap || len(child) == 0 {
+			leaves[k] = v
+			continue
+		}
+		for leaf, value := range valueLeaves(child) {
+			leaves[k+pathSeparator+leaf] = value
+		}
+	}
+	return leaves
+}
+
+// jsonRoundTrip returns values as they decode from JSON
+func jsonRoundTrip(values map[string]interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode values: %w", err)
+	}
+	out := map[string]interface{}{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("failed to decode values: %w", err)
+	}
+	return out, nil
+}
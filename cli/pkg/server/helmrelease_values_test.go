@@ -0,0 +1,355 @@
+// Copyright 2025 Laszlo Consulting Kft.
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	helmv2 "github.com/fluxcd/helm-controller/api/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/yaml"
+)
+
+func parseHelmRelease(t *testing.T, doc string) *helmv2.HelmRelease {
+	t.Helper()
+	hr := &helmv2.HelmRelease{}
+	if err := yaml.Unmarshal([]byte(doc), hr); err != nil {
+		t.Fatal(err)
+	}
+	return hr
+}
+
+func parseJSONValues(t *testing.T, doc string) map[string]interface{} {
+	t.Helper()
+	values := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(doc), &values); err != nil {
+		t.Fatal(err)
+	}
+	return values
+}
+
+// valuesFromObjects are the ConfigMaps and Secrets the HelmReleases of the values tests reference
+func valuesFromObjects() *fake.Clientset {
+	return fake.NewClientset(
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "base", Namespace: "apps"},
+			Data: map[string]string{
+				"values.yaml": "replicaCount: 1\nimage:\n  repository: nginx\n  tag: \"1.25\"\n",
+				"replicas":    "2",
+			},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "creds", Namespace: "apps"},
+			Data: map[string][]byte{
+				"password": []byte("s3cr3t"),
+				"values":   []byte("auth:\n  enabled: true\n  user: admin\n"),
+			},
+		},
+	)
+}
+
+func TestHelmReleaseValues(t *testing.T) {
+	tests := []struct {
+		name    string
+		release string
+		want    string // JSON, empty if composing fails
+		wantErr string
+	}{
+		{
+			name: "spec.values only",
+			release: `
+metadata: {name: web, namespace: apps}
+spec:
+  values: {replicaCount: 3}
+`,
+			want: `{"replicaCount": 3}`,
+		},
+		{
+			name: "valuesFrom in order, spec.values on top",
+			release: `
+metadata: {name: web, namespace: apps}
+spec:
+  valuesFrom:
+  - {kind: ConfigMap, name: base}
+  - {kind: Secret, name: creds, valuesKey: values}
+  values:
+    image: {tag: "1.27"}
+    auth: {user: root}
+`,
+			want: `{"replicaCount": 1, "image": {"repository": "nginx", "tag": "1.27"}, "auth": {"enabled": true, "user": "root"}}`,
+		},
+		{
+			name: "target paths",
+			release: `
+metadata: {name: web, namespace: apps}
+spec:
+  valuesFrom:
+  - {kind: ConfigMap, name: base}
+  - {kind: ConfigMap, name: base, valuesKey: replicas, targetPath: replicaCount}
+  - {kind: Secret, name: creds, valuesKey: password, targetPath: auth.password}
+`,
+			want: `{"replicaCount": 2, "image": {"repository": "nginx", "tag": "1.25"}, "auth": {"password": "s3cr3t"}}`,
+		},
+		{
+			name: "optional references that don't exist",
+			release: `
+metadata: {name: web, namespace: apps}
+spec:
+  valuesFrom:
+  - {kind: ConfigMap, name: overrides, optional: true}
+  - {kind: Secret, name: creds, valuesKey: missing, optional: true}
+  values: {replicaCount: 3}
+`,
+			want: `{"replicaCount": 3}`,
+		},
+		{
+			name: "required reference that doesn't exist",
+			release: `
+metadata: {name: web, namespace: apps}
+spec:
+  valuesFrom:
+  - {kind: ConfigMap, name: overrides}
+`,
+			wantErr: "values key values.yaml of ConfigMap apps/overrides not found",
+		},
+		{
+			name: "unsupported kind",
+			release: `
+metadata: {name: web, namespace: apps}
+spec:
+  valuesFrom:
+  - {kind: HelmRepository, name: base}
+`,
+			wantErr: "unsupported values reference kind HelmRepository",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hr := parseHelmRelease(t, tt.release)
+			values, _, err := helmReleaseValues(context.Background(), valuesFromObjects().CoreV1(), hr)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("helmReleaseValues() error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("helmReleaseValues() error = %v", err)
+			}
+			// Compare as the API returns them, numbers differ in type depending on the source
+			got, err := jsonRoundTrip(values)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := parseJSONValues(t, tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("helmReleaseValues() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestReplacePathValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  string
+		path    string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{name: "number", values: `{}`, path: "replicaCount", value: "3", want: `{"replicaCount": 3}`},
+		{name: "bool", values: `{}`, path: "auth.enabled", value: "true", want: `{"auth": {"enabled": true}}`},
+		{name: "double quoted number", values: `{}`, path: "image.tag", value: `"1"`, want: `{"image": {"tag": "1"}}`},
+		{name: "single quoted bool", values: `{}`, path: "flag", value: `'false'`, want: `{"flag": "false"}`},
+		{
+			name:   "keeps sibling values",
+			values: `{"image": {"repository": "nginx", "tag": "1.25"}}`,
+			path:   "image.tag",
+			value:  "latest",
+			want:   `{"image": {"repository": "nginx", "tag": "latest"}}`,
+		},
+		{name: "list index", values: `{}`, path: "args[0]", value: "--debug", want: `{"args": ["--debug"]}`},
+		{name: "invalid index", values: `{}`, path: "args[x]", value: "--debug", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values := parseJSONValues(t, tt.values)
+			err := replacePathValue(values, tt.path, tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("replacePathValue() = %v, want an error", values)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("replacePathValue() error = %v", err)
+			}
+			got, err := jsonRoundTrip(values)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := parseJSONValues(t, tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("replacePathValue() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+// formatOrigins formats origins as path, with | between keys, to the name of the source and its key
+func formatOrigins(origins valueOrigins) map[string]string {
+	out := map[string]string{}
+	for key, source := range origins {
+		out[strings.ReplaceAll(key, pathSeparator, "|")] = source.Name + ":" + source.Key
+	}
+	return out
+}
+
+func TestMergeValuesOrigins(t *testing.T) {
+	base := ValueSource{Kind: "ConfigMap", Name: "base", Key: "values.yaml"}
+	spec := ValueSource{Kind: helmv2.HelmReleaseKind, Name: "web", Key: "spec.values"}
+
+	tests := []struct {
+		name string
+		base string
+		spec string
+		want map[string]string
+	}{
+		{
+			name: "leaves of the last source win",
+			base: `{"image": {"repository": "nginx", "tag": "1.25"}, "replicaCount": 1}`,
+			spec: `{"image": {"tag": "1.27"}}`,
+			want: map[string]string{
+				"image|repository": "base:values.yaml",
+				"image|tag":        "web:spec.values",
+				"replicaCount":     "base:values.yaml",
+			},
+		},
+		{
+			name: "map replaced by a scalar",
+			base: `{"ingress": {"enabled": true, "host": "example.com"}}`,
+			spec: `{"ingress": false}`,
+			want: map[string]string{"ingress": "web:spec.values"},
+		},
+		{
+			name: "scalar replaced by a map",
+			base: `{"ingress": false}`,
+			spec: `{"ingress": {"enabled": true}}`,
+			want: map[string]string{"ingress|enabled": "web:spec.values"},
+		},
+		{
+			name: "empty map and list are leaves",
+			base: `{"podAnnotations": {"a": "1"}, "args": ["--a"]}`,
+			spec: `{"podAnnotations": {}, "args": []}`,
+			want: map[string]string{"podAnnotations|a": "base:values.yaml", "args": "web:spec.values"},
+		},
+		{
+			name: "keys with dots",
+			base: `{"podAnnotations": {"prometheus.io/scrape": "true"}}`,
+			spec: `{"podAnnotations": {"prometheus.io/port": "8080"}}`,
+			want: map[string]string{
+				"podAnnotations|prometheus.io/scrape": "base:values.yaml",
+				"podAnnotations|prometheus.io/port":   "web:spec.values",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			origins := valueOrigins{}
+			values := mergeValues(map[string]interface{}{}, parseJSONValues(t, tt.base), nil, origins, base)
+			mergeValues(values, parseJSONValues(t, tt.spec), nil, origins, spec)
+			if got := formatOrigins(origins); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("origins = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHelmReleaseValueOrigins(t *testing.T) {
+	hr := parseHelmRelease(t, `
+metadata: {name: web, namespace: apps}
+spec:
+  valuesFrom:
+  - {kind: ConfigMap, name: base}
+  - {kind: ConfigMap, name: base, valuesKey: replicas, targetPath: replicaCount}
+  - {kind: Secret, name: creds, valuesKey: password, targetPath: auth.password}
+  values:
+    image: {tag: "1.27"}
+`)
+	_, origins, err := helmReleaseValues(context.Background(), valuesFromObjects().CoreV1(), hr)
+	if err != nil {
+		t.Fatalf("helmReleaseValues() error = %v", err)
+	}
+	want := map[string]string{
+		"replicaCount":     "base:replicas",
+		"image|repository": "base:values.yaml",
+		"image|tag":        "web:spec.values",
+		"auth|password":    "creds:password",
+	}
+	if got := formatOrigins(origins); !reflect.DeepEqual(got, want) {
+		t.Errorf("origins = %v, want %v", got, want)
+	}
+	if source := origins["auth"+pathSeparator+"password"]; source.Kind != "Secret" || source.TargetPath != "auth.password" {
+		t.Errorf("origin of auth.password = %+v, want the Secret with its target path", source)
+	}
+}
+
+func TestCompareValueLeaves(t *testing.T) {
+	values := parseJSONValues(t, `{"replicaCount": 2, "image": {"tag": "1.27", "pullPolicy": "Always"}, "args": ["--a"], "podAnnotations": {}}`)
+	deployed := parseJSONValues(t, `{"replicaCount": 2, "image": {"tag": "1.25"}, "args": ["--a"], "debug": true}`)
+	spec := ValueSource{Kind: helmv2.HelmReleaseKind, Name: "web", Key: "spec.values"}
+	origins := valueOrigins{}
+	mergeValues(map[string]interface{}{}, values, nil, origins, spec)
+
+	tests := []struct {
+		name     string
+		deployed map[string]interface{}
+		want     []string // path and status
+	}{
+		{
+			name:     "compared to the deployed values",
+			deployed: deployed,
+			want: []string{
+				"args unchanged",
+				"debug removed",
+				"image.pullPolicy added",
+				"image.tag changed",
+				"podAnnotations added",
+				"replicaCount unchanged",
+			},
+		},
+		{
+			name: "release not readable",
+			want: []string{"args ", "image.pullPolicy ", "image.tag ", "podAnnotations ", "replicaCount "},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leaves := compareValueLeaves(values, tt.deployed, origins)
+			var got []string
+			for _, leaf := range leaves {
+				got = append(got, strings.Join(leaf.Path, ".")+" "+leaf.Status)
+				if leaf.Status == valueRemoved {
+					if leaf.Source != nil || leaf.Deployed == nil {
+						t.Errorf("removed leaf %v = %+v, want only the deployed value", leaf.Path, leaf)
+					}
+				} else if leaf.Source == nil || leaf.Source.Key != "spec.values" {
+					t.Errorf("leaf %v has source %+v, want spec.values", leaf.Path, leaf.Source)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("leaves = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/gimlet-io/capacitor/pkg/helm"
 	"github.com/gimlet-io/capacitor/pkg/kubernetes"
 	"github.com/labstack/echo/v4"
 	"k8s.io/client-go/discovery"
@@ -30,10 +31,16 @@ type KubernetesProxy struct {
 	fluxAPIPaths     map[string]string // Cache for discovered Flux API paths (kind -> API path template)
 	fluxAPIPathsMu   sync.RWMutex      // Mutex for thread-safe access to fluxAPIPaths
 	fluxAPIDiscovery sync.Once         // Ensures discovery happens only once
+
+	helmStorage    helm.Storage // Where the context keeps Helm releases
+	helmClient     *helm.Client // Shared by every Helm request of the context, see HelmClient
+	helmClientErr  error
+	helmClientOnce sync.Once
+	helmJobs       *helm.Jobs // Background Helm actions of the context, like rollbacks
 }
 
-// NewKubernetesProxy creates a new KubernetesProxy
-func NewKubernetesProxy(k8sClient *kubernetes.Client, accessLogEnabled bool) (*KubernetesProxy, error) {
+// NewKubernetesProxy creates a new KubernetesProxy. Its Helm client reads releases from helmStorage.
+func NewKubernetesProxy(k8sClient *kubernetes.Client, accessLogEnabled bool, helmStorage helm.Storage) (*KubernetesProxy, error) {
 	// Get the Kubernetes API server URL from the client config
 	apiServerURL, err := url.Parse(k8sClient.Config.Host)
 	if err != nil {
@@ -135,6 +142,8 @@ func NewKubernetesProxy(k8sClient *kubernetes.Client, accessLogEnabled bool) (*K
 		proxy:            proxy,
 		accessLogEnabled: accessLogEnabled,
 		fluxAPIPaths:     make(map[string]string),
+		helmStorage:      helmStorage,
+		helmJobs:         helm.NewJobs(),
 	}, nil
 }
 
@@ -155,8 +164,8 @@ func (p *KubernetesProxy) HandleAPIRequest(c echo.Context) error {
 		log.Printf("Proxying request: %s %s", c.Request().Method, path)
 	}
 
-	// Proxy the request
-	p.proxy.ServeHTTP(c.Response().Writer, c.Request())
+	// Proxy the request. Writing through echo's Response keeps track of the status code for the audit log.
+	p.proxy.ServeHTTP(c.Response(), c.Request())
 
 	return nil
 }
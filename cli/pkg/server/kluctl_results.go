@@ -12,9 +12,13 @@ import (
 	"time"
 
 	"github.com/gimlet-io/capacitor/pkg/kubernetes"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// kluctlCommandResultLabel is set on the Secrets Kluctl stores command results in
+const kluctlCommandResultLabel = "kluctl.io/command-result-id"
+
 // KluctlDeploymentPseudoResource represents a grouped view of Kluctl command results
 // for a single resource discriminator. It is rendered to a Kubernetes-style object
 // with apiVersion kluctl.io/v1, kind Deployment.
@@ -27,6 +31,7 @@ type KluctlDeploymentPseudoResource struct {
 }
 
 // KluctlDeploymentMetadata models standard Kubernetes metadata for the pseudo resource.
+// CreationTimestamp is the start time of the latest command, the UI computes the age from it.
 type KluctlDeploymentMetadata struct {
 	Name              string `json:"name"`
 	Namespace         string `json:"namespace"`
@@ -41,7 +46,6 @@ type KluctlDeploymentSpec struct {
 
 // KluctlDeploymentStatus contains aggregated status and full command summaries.
 type KluctlDeploymentStatus struct {
-	AgeSeconds          int64                  `json:"ageSeconds"`
 	LatestResult        CommandResultSummary   `json:"latestResult"`
 	CommandSummaries    []CommandResultSummary `json:"commandSummaries"`
 	LatestReducedResult string                 `json:"latestReducedResult,omitempty"`
@@ -70,14 +74,7 @@ func GroupCommandResultSummaries(summaries []CommandResultSummary) []KluctlDeplo
 	groups := map[KluctlDeploymentKey][]CommandResultSummary{}
 
 	for _, s := range summaries {
-		key := KluctlDeploymentKey{
-			Project: s.ProjectKey,
-			Target:  s.TargetKey,
-		}
-		if s.KluctlDeployment != nil {
-			key.KDName = s.KluctlDeployment.Name
-			key.KDNamespace = s.KluctlDeployment.Namespace
-		}
+		key := kluctlDeploymentKeyFor(s)
 		groups[key] = append(groups[key], s)
 	}
 
@@ -108,6 +105,19 @@ func GroupCommandResultSummaries(summaries []CommandResultSummary) []KluctlDeplo
 	return result
 }
 
+// kluctlDeploymentKeyFor returns the resource discriminator a summary is grouped by
+func kluctlDeploymentKeyFor(s CommandResultSummary) KluctlDeploymentKey {
+	key := KluctlDeploymentKey{
+		Project: s.ProjectKey,
+		Target:  s.TargetKey,
+	}
+	if s.KluctlDeployment != nil {
+		key.KDName = s.KluctlDeployment.Name
+		key.KDNamespace = s.KluctlDeployment.Namespace
+	}
+	return key
+}
+
 // lessCommandSummaryForUI orders summaries newest-first, based on the same
 // fields used in kluctl's lessCommandSummary helper.
 func lessCommandSummaryForUI(a, b *CommandResultSummary) bool {
@@ -157,15 +167,6 @@ func BuildKluctlDeploymentObject(g KluctlDeploymentGroup, payloads map[string]Co
 		namespace = latest.KluctlDeployment.Namespace
 	}
 
-	// Compute age from latest command start time.
-	ageSeconds := int64(0)
-	if !latest.Command.StartTime.IsZero() {
-		ageSeconds = int64(time.Since(latest.Command.StartTime).Seconds())
-		if ageSeconds < 0 {
-			ageSeconds = 0
-		}
-	}
-
 	meta := KluctlDeploymentMetadata{
 		Name:              sanitizeKluctlName(name),
 		Namespace:         namespace,
@@ -178,7 +179,6 @@ func BuildKluctlDeploymentObject(g KluctlDeploymentGroup, payloads map[string]Co
 	}
 
 	status := KluctlDeploymentStatus{
-		AgeSeconds:       ageSeconds,
 		LatestResult:     latest,
 		CommandSummaries: summaries,
 	}
@@ -338,7 +338,7 @@ func ListCommandResultSummariesWithPayload(ctx context.Context, k8sClient *kuber
 
 	secretClient := k8sClient.Clientset.CoreV1().Secrets(commandResultNamespace)
 	secretList, err := secretClient.List(ctx, metav1.ListOptions{
-		LabelSelector: "kluctl.io/command-result-id",
+		LabelSelector: kluctlCommandResultLabel,
 	})
 	if err != nil {
 		return nil, nil, err
@@ -346,44 +346,54 @@ func ListCommandResultSummariesWithPayload(ctx context.Context, k8sClient *kuber
 
 	summaries := make([]CommandResultSummary, 0, len(secretList.Items))
 	payloads := make(map[string]CommandResultPayload, len(secretList.Items))
-	for _, s := range secretList.Items {
-		ann := s.Annotations["kluctl.io/command-result-summary"]
-		if ann == "" {
+	for i := range secretList.Items {
+		summary, payload, ok := commandResultFromSecret(&secretList.Items[i])
+		if !ok {
 			continue
 		}
-		var summary CommandResultSummary
-		log.Printf("unmarshalling command result summary: %s", ann)
-		// Log secret data contents (decoded bytes as text, truncated) for debugging.
-		if err := json.Unmarshal([]byte(ann), &summary); err != nil {
-			log.Printf("failed to unmarshal command result summary: %v", err)
-			continue
+		if payload != nil {
+			payloads[summary.Id] = *payload
 		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, payloads, nil
+}
 
-		var reducedJSON, compactedJSON string
-		if data, ok := s.Data["reducedResult"]; ok && len(data) > 0 {
-			if txt, err := gunzipToString(data); err != nil {
-				log.Printf("failed to gunzip reducedResult for %s/%s: %v", s.Namespace, s.Name, err)
-			} else {
-				reducedJSON = txt
-			}
-		}
-		if data, ok := s.Data["compactedObjects"]; ok && len(data) > 0 {
-			if txt, err := gunzipToString(data); err != nil {
-				log.Printf("failed to gunzip compactedObjects for %s/%s: %v", s.Namespace, s.Name, err)
-			} else {
-				compactedJSON = txt
-			}
+// commandResultFromSecret reads the command result summary from the annotation of a result Secret,
+// and the decoded JSON payloads from its data. The payload is nil if the Secret has none.
+func commandResultFromSecret(s *corev1.Secret) (CommandResultSummary, *CommandResultPayload, bool) {
+	var summary CommandResultSummary
+	ann := s.Annotations["kluctl.io/command-result-summary"]
+	if ann == "" {
+		return summary, nil, false
+	}
+	if err := json.Unmarshal([]byte(ann), &summary); err != nil {
+		log.Printf("failed to unmarshal command result summary: %v", err)
+		return summary, nil, false
+	}
+
+	var reducedJSON, compactedJSON string
+	if data, ok := s.Data["reducedResult"]; ok && len(data) > 0 {
+		if txt, err := gunzipToString(data); err != nil {
+			log.Printf("failed to gunzip reducedResult for %s/%s: %v", s.Namespace, s.Name, err)
+		} else {
+			reducedJSON = txt
 		}
-		if reducedJSON != "" || compactedJSON != "" {
-			payloads[summary.Id] = CommandResultPayload{
-				ReducedResultJSON:    reducedJSON,
-				CompactedObjectsJSON: compactedJSON,
-			}
+	}
+	if data, ok := s.Data["compactedObjects"]; ok && len(data) > 0 {
+		if txt, err := gunzipToString(data); err != nil {
+			log.Printf("failed to gunzip compactedObjects for %s/%s: %v", s.Namespace, s.Name, err)
+		} else {
+			compactedJSON = txt
 		}
-
-		summaries = append(summaries, summary)
 	}
-	return summaries, payloads, nil
+	if reducedJSON == "" && compactedJSON == "" {
+		return summary, nil, true
+	}
+	return summary, &CommandResultPayload{
+		ReducedResultJSON:    reducedJSON,
+		CompactedObjectsJSON: compactedJSON,
+	}, true
 }
 
 // ListCommandResultSummaries is a compatibility wrapper that returns only summaries.
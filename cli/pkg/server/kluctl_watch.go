@@ -0,0 +1,182 @@
+// Copyright 2025 Laszlo Consulting Kft.
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/url"
+
+	"github.com/gimlet-io/capacitor/pkg/kubernetes"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// kluctlResultSecretsPath returns the API path of the Kluctl command result Secrets in a namespace
+func kluctlResultSecretsPath(commandResultNamespace string) string {
+	return "/api/v1/namespaces/" + url.PathEscape(commandResultNamespace) +
+		"/secrets?labelSelector=" + url.QueryEscape(kluctlCommandResultLabel)
+}
+
+// kluctlDeploymentTracker turns watch events of Kluctl command result Secrets into watch events
+// of KluctlDeploymentPseudoResources. A changed Secret only rebuilds the deployment it belongs to,
+// and an event is only produced if the deployment actually changed. It is not safe for concurrent use.
+type kluctlDeploymentTracker struct {
+	commandResultNamespace string
+	namespaceFilter        string
+
+	results map[string]kluctlResult                 // keyed by Secret name
+	sent    map[KluctlDeploymentKey]json.RawMessage // deployments the client has
+}
+
+type kluctlResult struct {
+	key     KluctlDeploymentKey
+	summary CommandResultSummary
+	payload *CommandResultPayload
+}
+
+func newKluctlDeploymentTracker(commandResultNamespace, namespaceFilter string) *kluctlDeploymentTracker {
+	return &kluctlDeploymentTracker{
+		commandResultNamespace: commandResultNamespace,
+		namespaceFilter:        namespaceFilter,
+		results:                make(map[string]kluctlResult),
+		sent:                   make(map[KluctlDeploymentKey]json.RawMessage),
+	}
+}
+
+// Apply processes a batch of Secret watch events and returns the ADDED, MODIFIED and DELETED
+// events of the pseudo Deployments that changed
+func (t *kluctlDeploymentTracker) Apply(events []*kubernetes.WatchEvent) []*kubernetes.WatchEvent {
+	affected := map[KluctlDeploymentKey]bool{}
+	var order []KluctlDeploymentKey
+	touch := func(key KluctlDeploymentKey) {
+		if !affected[key] {
+			affected[key] = true
+			order = append(order, key)
+		}
+	}
+
+	for _, event := range events {
+		if event.Type == kubernetes.SyncedEventType {
+			for _, key := range t.prune(event.Object) {
+				touch(key)
+			}
+			continue
+		}
+
+		var secret corev1.Secret
+		if err := json.Unmarshal(event.Object, &secret); err != nil || secret.Name == "" {
+			continue
+		}
+		if prev, ok := t.results[secret.Name]; ok {
+			// The summary may have moved the result to another deployment
+			touch(prev.key)
+			delete(t.results, secret.Name)
+		}
+		if event.Type == "DELETED" {
+			continue
+		}
+
+		summary, payload, ok := commandResultFromSecret(&secret)
+		if !ok {
+			continue
+		}
+		result := kluctlResult{key: kluctlDeploymentKeyFor(summary), summary: summary, payload: payload}
+		t.results[secret.Name] = result
+		touch(result.key)
+	}
+
+	var out []*kubernetes.WatchEvent
+	for _, key := range order {
+		if ev := t.rebuild(key); ev != nil {
+			out = append(out, ev)
+		}
+	}
+	return out
+}
+
+// rebuild recomputes a single deployment from its results and compares it with what was sent
+func (t *kluctlDeploymentTracker) rebuild(key KluctlDeploymentKey) *kubernetes.WatchEvent {
+	var summaries []CommandResultSummary
+	payloads := map[string]CommandResultPayload{}
+	for _, result := range t.results {
+		if result.key != key {
+			continue
+		}
+		summaries = append(summaries, result.summary)
+		if result.payload != nil {
+			payloads[result.summary.Id] = *result.payload
+		}
+	}
+
+	prev, wasSent := t.sent[key]
+
+	var obj *KluctlDeploymentPseudoResource
+	if groups := GroupCommandResultSummaries(summaries); len(groups) == 1 {
+		built := BuildKluctlDeploymentObject(groups[0], payloads)
+		// Ensure a namespace is always present on the pseudo Deployment; fall back to
+		// the command result namespace when KluctlDeploymentInfo.Namespace is absent.
+		if built.Metadata.Namespace == "" {
+			built.Metadata.Namespace = t.commandResultNamespace
+		}
+		if t.namespaceFilter == "" || built.Metadata.Namespace == t.namespaceFilter {
+			obj = &built
+		}
+	}
+
+	if obj == nil {
+		if !wasSent {
+			return nil
+		}
+		delete(t.sent, key)
+		return &kubernetes.WatchEvent{Type: "DELETED", Object: prev}
+	}
+
+	data, err := json.Marshal(kluctlDeploymentWireObject(*obj))
+	if err != nil {
+		log.Printf("Error marshaling Kluctl deployment object: %v", err)
+		return nil
+	}
+	if wasSent && bytes.Equal(prev, data) {
+		return nil
+	}
+	t.sent[key] = data
+	if wasSent {
+		return &kubernetes.WatchEvent{Type: "MODIFIED", Object: data}
+	}
+	return &kubernetes.WatchEvent{Type: "ADDED", Object: data}
+}
+
+// prune drops the results missing from the re-list described by a SYNCED event
+// and returns the deployments they belonged to
+func (t *kluctlDeploymentTracker) prune(raw json.RawMessage) []KluctlDeploymentKey {
+	var synced kubernetes.WatchSync
+	if err := json.Unmarshal(raw, &synced); err != nil {
+		return nil
+	}
+	listed := make(map[string]struct{}, len(synced.Objects))
+	for _, name := range synced.Objects {
+		listed[name] = struct{}{}
+	}
+
+	var keys []KluctlDeploymentKey
+	for name, result := range t.results {
+		if _, ok := listed[t.commandResultNamespace+"/"+name]; !ok {
+			delete(t.results, name)
+			keys = append(keys, result.key)
+		}
+	}
+	return keys
+}
+
+// kluctlDeploymentWireObject is the shape a pseudo Deployment is sent to the UI in
+func kluctlDeploymentWireObject(obj KluctlDeploymentPseudoResource) map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": obj.APIVersion,
+		"kind":       obj.Kind,
+		"metadata":   obj.Metadata,
+		"spec":       obj.Spec,
+		"status":     obj.Status,
+	}
+}
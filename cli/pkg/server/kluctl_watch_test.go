@@ -0,0 +1,138 @@
+// Copyright 2025 Laszlo Consulting Kft.
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gimlet-io/capacitor/pkg/kubernetes"
+)
+
+// kluctlResultEvent builds a watch event of a Kluctl command result Secret
+func kluctlResultEvent(t *testing.T, typ, secretName string, summary CommandResultSummary) *kubernetes.WatchEvent {
+	t.Helper()
+	annotation, err := json.Marshal(summary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj, err := json.Marshal(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata": map[string]interface{}{
+			"name":        secretName,
+			"namespace":   "kluctl-results",
+			"labels":      map[string]string{kluctlCommandResultLabel: summary.Id},
+			"annotations": map[string]string{"kluctl.io/command-result-summary": string(annotation)},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &kubernetes.WatchEvent{Type: typ, Object: obj}
+}
+
+func kluctlSummary(id, deployment, namespace string, start time.Time, changes int) CommandResultSummary {
+	return CommandResultSummary{
+		Id:               id,
+		ProjectKey:       ProjectKey{RepoKey: "github.com/acme/infra"},
+		TargetKey:        TargetKey{TargetName: "prod"},
+		Command:          CommandInfo{StartTime: start, EndTime: start.Add(time.Minute), Command: "deploy"},
+		KluctlDeployment: &KluctlDeploymentInfo{Name: deployment, Namespace: namespace},
+		TotalChanges:     changes,
+	}
+}
+
+// formatKluctlEvents formats pseudo Deployment events as "TYPE namespace/name results"
+func formatKluctlEvents(t *testing.T, events []*kubernetes.WatchEvent) []string {
+	t.Helper()
+	var out []string
+	for _, ev := range events {
+		var obj KluctlDeploymentPseudoResource
+		if err := json.Unmarshal(ev.Object, &obj); err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, fmt.Sprintf("%s %s/%s %d", ev.Type, obj.Metadata.Namespace, obj.Metadata.Name, len(obj.Status.CommandSummaries)))
+	}
+	return out
+}
+
+func TestKluctlDeploymentTracker(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	tracker := newKluctlDeploymentTracker("kluctl-results", "")
+
+	steps := []struct {
+		name   string
+		events []*kubernetes.WatchEvent
+		want   []string
+	}{
+		{
+			name: "initial list",
+			events: []*kubernetes.WatchEvent{
+				kluctlResultEvent(t, "ADDED", "r1", kluctlSummary("r1", "web", "apps", start, 0)),
+				kluctlResultEvent(t, "ADDED", "r2", kluctlSummary("r2", "web", "apps", start.Add(time.Hour), 0)),
+				kluctlResultEvent(t, "ADDED", "r3", kluctlSummary("r3", "db", "", start, 0)),
+			},
+			want: []string{"ADDED apps/web 2", "ADDED kluctl-results/db 1"},
+		},
+		{
+			name:   "new result",
+			events: []*kubernetes.WatchEvent{kluctlResultEvent(t, "ADDED", "r4", kluctlSummary("r4", "web", "apps", start.Add(2*time.Hour), 1))},
+			want:   []string{"MODIFIED apps/web 3"},
+		},
+		{
+			name:   "result resent unchanged",
+			events: []*kubernetes.WatchEvent{kluctlResultEvent(t, "MODIFIED", "r4", kluctlSummary("r4", "web", "apps", start.Add(2*time.Hour), 1))},
+		},
+		{
+			name: "secret without a summary",
+			events: []*kubernetes.WatchEvent{{
+				Type:   "ADDED",
+				Object: json.RawMessage(`{"metadata":{"name":"other","namespace":"kluctl-results"}}`),
+			}},
+		},
+		{
+			name:   "result moved to another deployment",
+			events: []*kubernetes.WatchEvent{kluctlResultEvent(t, "MODIFIED", "r3", kluctlSummary("r3", "web", "apps", start, 0))},
+			want:   []string{"DELETED kluctl-results/db 1", "MODIFIED apps/web 4"},
+		},
+		{
+			name:   "latest result deleted",
+			events: []*kubernetes.WatchEvent{kluctlResultEvent(t, "DELETED", "r4", kluctlSummary("r4", "web", "apps", start.Add(2*time.Hour), 1))},
+			want:   []string{"MODIFIED apps/web 3"},
+		},
+		{
+			name:   "re-list without the deployment's results",
+			events: []*kubernetes.WatchEvent{syncedEvent()},
+			want:   []string{"DELETED apps/web 3"},
+		},
+	}
+
+	for _, step := range steps {
+		got := formatKluctlEvents(t, tracker.Apply(step.events))
+		if fmt.Sprint(got) != fmt.Sprint(step.want) {
+			t.Fatalf("%s: got %v, want %v", step.name, got, step.want)
+		}
+	}
+}
+
+func TestKluctlDeploymentTrackerNamespaceFilter(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	tracker := newKluctlDeploymentTracker("kluctl-results", "apps")
+
+	got := formatKluctlEvents(t, tracker.Apply([]*kubernetes.WatchEvent{
+		kluctlResultEvent(t, "ADDED", "r1", kluctlSummary("r1", "web", "apps", start, 0)),
+		kluctlResultEvent(t, "ADDED", "r2", kluctlSummary("r2", "db", "data", start, 0)),
+	}))
+	if fmt.Sprint(got) != fmt.Sprint([]string{"ADDED apps/web 1"}) {
+		t.Fatalf("got %v, want only the deployment in the filtered namespace", got)
+	}
+}
+
+func syncedEvent(objects ...string) *kubernetes.WatchEvent {
+	obj, _ := json.Marshal(kubernetes.WatchSync{Objects: objects})
+	return &kubernetes.WatchEvent{Type: kubernetes.SyncedEventType, Object: obj}
+}
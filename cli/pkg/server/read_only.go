@@ -0,0 +1,64 @@
+// Copyright 2025 Laszlo Consulting Kft.
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// checkReadOnlyProxyRequest returns an error if a Kubernetes API proxy request is not allowed in read-only mode.
+// Only GET and HEAD are let through, and the only connection upgrade allowed is a plain watch:
+// exec, attach, port-forward and the like are upgrades on GET requests too, so they are rejected explicitly.
+// The proxy subresource of pods, services and nodes is rejected for every method, as a GET through it
+// reaches in-cluster HTTP endpoints that may well change state.
+func checkReadOnlyProxyRequest(r *http.Request, apiPath string) error {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return fmt.Errorf("%s requests are not allowed in read-only mode", r.Method)
+	}
+
+	if isProxySubresource(apiPath) {
+		return fmt.Errorf("proxy is not allowed in read-only mode")
+	}
+
+	if r.Header.Get("Upgrade") == "" && !strings.Contains(strings.ToLower(r.Header.Get("Connection")), "upgrade") {
+		return nil
+	}
+
+	watch := r.URL.Query().Get("watch")
+	if !strings.EqualFold(watch, "true") && watch != "1" {
+		return fmt.Errorf("connection upgrades are only allowed for watches in read-only mode")
+	}
+
+	for _, segment := range strings.Split(strings.Trim(apiPath, "/"), "/") {
+		switch segment {
+		case "exec", "attach", "portforward", "proxy":
+			return fmt.Errorf("%s is not allowed in read-only mode", segment)
+		}
+	}
+
+	return nil
+}
+
+// isProxySubresource reports whether an API path goes through the proxy subresource of a pod, service or node,
+// e.g. api/v1/namespaces/default/services/web:80/proxy/admin, or the legacy api/v1/proxy/... paths
+func isProxySubresource(apiPath string) bool {
+	segments := strings.Split(strings.Trim(apiPath, "/"), "/")
+	for i, segment := range segments {
+		if segment != "proxy" {
+			continue
+		}
+		if i == 2 && segments[0] == "api" {
+			return true
+		}
+		if i >= 2 {
+			switch segments[i-2] {
+			case "pods", "services", "nodes":
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+// Copyright 2025 Laszlo Consulting Kft.
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCheckReadOnlyProxyRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		headers map[string]string
+		allowed bool
+	}{
+		{name: "list pods", method: http.MethodGet, path: "api/v1/namespaces/default/pods", allowed: true},
+		{name: "head", method: http.MethodHead, path: "api/v1/namespaces/default/pods/web", allowed: true},
+		{name: "pod named proxy", method: http.MethodGet, path: "api/v1/namespaces/default/pods/proxy", allowed: true},
+		{name: "namespace named proxy", method: http.MethodGet, path: "api/v1/namespaces/proxy/pods", allowed: true},
+		{
+			name:    "watch",
+			method:  http.MethodGet,
+			path:    "apis/apps/v1/deployments?watch=true",
+			headers: map[string]string{"Connection": "Upgrade", "Upgrade": "websocket"},
+			allowed: true,
+		},
+		{name: "post", method: http.MethodPost, path: "api/v1/namespaces/default/pods"},
+		{name: "patch", method: http.MethodPatch, path: "apis/apps/v1/namespaces/default/deployments/web"},
+		{name: "delete", method: http.MethodDelete, path: "api/v1/namespaces/default/pods/web"},
+		{
+			name:    "exec",
+			method:  http.MethodGet,
+			path:    "api/v1/namespaces/default/pods/web/exec?command=sh",
+			headers: map[string]string{"Connection": "Upgrade", "Upgrade": "SPDY/3.1"},
+		},
+		{
+			name:    "exec disguised as a watch",
+			method:  http.MethodGet,
+			path:    "api/v1/namespaces/default/pods/web/exec?watch=true",
+			headers: map[string]string{"Connection": "Upgrade", "Upgrade": "SPDY/3.1"},
+		},
+		{name: "service proxy", method: http.MethodGet, path: "api/v1/namespaces/default/services/web:80/proxy/admin"},
+		{name: "pod proxy", method: http.MethodGet, path: "api/v1/namespaces/default/pods/web/proxy/"},
+		{name: "node proxy", method: http.MethodGet, path: "api/v1/nodes/worker-1/proxy/configz"},
+		{name: "legacy proxy", method: http.MethodGet, path: "api/v1/proxy/namespaces/default/services/web/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/k8s/prod/"+tt.path, nil)
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			err := checkReadOnlyProxyRequest(r, strings.TrimPrefix(r.URL.Path, "/k8s/prod/"))
+			if tt.allowed && err != nil {
+				t.Errorf("checkReadOnlyProxyRequest() error = %v, want allowed", err)
+			}
+			if !tt.allowed && err == nil {
+				t.Errorf("checkReadOnlyProxyRequest() allowed %s %s, want an error", tt.method, tt.path)
+			}
+		})
+	}
+}
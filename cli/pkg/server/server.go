@@ -18,11 +18,13 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/gimlet-io/capacitor/pkg/audit"
 	"github.com/gimlet-io/capacitor/pkg/config"
 	"github.com/gimlet-io/capacitor/pkg/helm"
 	"github.com/gimlet-io/capacitor/pkg/kubernetes"
@@ -69,6 +71,10 @@ type Server struct {
 	k8sProxiesMu sync.RWMutex
 	embedFS      fs.FS // embedded file system for static files
 	version      string
+	authToken    string // per-process session token, see auth.go
+	audit        *audit.Log
+	osUser       string
+	watchMux     *kubernetes.WatchMultiplexer // upstream watches shared by all WebSocket connections
 }
 
 // proxyContextKey is the type used to store the KubernetesProxy in the request context
@@ -117,6 +123,8 @@ type ConfigResponse struct {
 	SystemViews map[string][]SystemView `json:"systemViews"`
 	FluxCD      FluxCDResponse          `json:"fluxcd"`
 	Carvel      CarvelResponse          `json:"carvel"`
+	// ReadOnly is true when the server refuses every mutating operation
+	ReadOnly bool `json:"readOnly"`
 }
 
 // defaultSystemViews contains the built‑in system views that were previously hardcoded in ViewBar.tsx.
@@ -214,17 +222,34 @@ func New(cfg *config.Config, k8sClient *kubernetes.Client, version string) (*Ser
 
 	// Initialize proxy cache and seed with current context
 	proxyCache := make(map[string]*KubernetesProxy)
-	initialProxy, err := NewKubernetesProxy(k8sClient, cfg.AccessLogEnabled)
+	initialProxy, err := NewKubernetesProxy(k8sClient, cfg.AccessLogEnabled, helmStorageFor(cfg, k8sClient.CurrentContext))
 	if err != nil {
 		return nil, fmt.Errorf("error creating kubernetes proxy: %w", err)
 	}
 	proxyCache[k8sClient.CurrentContext] = initialProxy
 
+	authToken, err := generateAuthToken()
+	if err != nil {
+		return nil, err
+	}
+
+	var auditLog *audit.Log
+	if cfg.AuditLogPath != "" {
+		auditLog, err = audit.New(cfg.AuditLogPath, int64(cfg.AuditLogMaxSizeMB)*1024*1024, cfg.AuditLogMaxBackups)
+		if err != nil {
+			return nil, fmt.Errorf("error opening audit log: %w", err)
+		}
+	}
+
 	return &Server{
 		echo:       e,
 		config:     cfg,
 		k8sProxies: proxyCache,
 		version:    version,
+		authToken:  authToken,
+		audit:      auditLog,
+		osUser:     currentOSUser(),
+		watchMux:   kubernetes.NewWatchMultiplexer(),
 	}, nil
 }
 
@@ -233,7 +258,14 @@ func (s *Server) Setup() {
 	// Add middleware
 	// s.echo.Use(middleware.Logger())
 	s.echo.Use(middleware.Recover())
-	s.echo.Use(middleware.CORS())
+	s.echo.Use(middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins:     []string{s.origin()},
+		AllowCredentials: true,
+	}))
+
+	// Require the session token for the API, the Kubernetes proxy and the WebSockets.
+	// Registered before the proxy middleware so unauthenticated requests don't create proxies.
+	s.echo.Use(s.authMiddleware)
 
 	// Attach Kubernetes proxy automatically for any route that includes a :context param
 	// This ensures handlers under /api/:context/... have access to the proxy without
@@ -312,16 +344,24 @@ func (s *Server) Setup() {
 			})
 		}
 
-		hc, err := helm.NewClient(proxy.k8sClient.Config, "")
+		hc, err := proxy.HelmClient()
 		if err != nil {
 			return c.JSON(http.StatusInternalServerError, map[string]string{
-				"error": fmt.Sprintf("failed to create helm client: %v", err),
+				"error": err.Error(),
 			})
 		}
 
 		// Create a per-connection handler so it uses the context-specific clients
 		// and respects the global access log toggle from config.
-		h := NewWebSocketHandler(proxy.k8sClient, hc, s.config.AccessLogEnabled)
+		h := NewWebSocketHandler(proxy.k8sClient, hc, s.watchMux, s.config.AccessLogEnabled, s.config.WatchMaxUpdateRate)
+		h.helmJobs = proxy.helmJobs
+		return h.HandleWebSocket(c)
+	})
+
+	// WebSocket endpoint not bound to a context: subscriptions list their contexts (or "*"),
+	// and events are tagged with the context they come from
+	s.echo.GET("/ws", func(c echo.Context) error {
+		h := NewMultiContextWebSocketHandler(s.k8sClientForContext, s.contextNames, s.watchMux, s.config.AccessLogEnabled, s.config.WatchMaxUpdateRate)
 		return h.HandleWebSocket(c)
 	})
 
@@ -357,9 +397,13 @@ func (s *Server) Setup() {
 					LabelValue:     s.config.Carvel.KappControllerLabelValue,
 				},
 			},
+			ReadOnly: s.config.ReadOnly,
 		})
 	})
 
+	// Audit log of mutating actions, filterable by query parameters
+	s.echo.GET("/api/audit", s.handleAuditQuery)
+
 	// Add endpoint for getting kubeconfig contexts
 	s.echo.GET("/api/contexts", func(c echo.Context) error {
 		tmpClient, err := kubernetes.NewClient(s.config.KubeConfigPath, s.config.InsecureSkipTLSVerify, "")
@@ -641,7 +685,7 @@ func (s *Server) Setup() {
 			"message": fmt.Sprintf("Successfully reconciled %s/%s", kind, resourceName),
 			"output":  output,
 		})
-	})
+	}, s.mutating("flux.reconcile"))
 
 	// Add endpoint for suspending Flux resources (context-aware)
 	s.echo.POST("/api/:context/flux/suspend", func(c echo.Context) error {
@@ -738,7 +782,7 @@ func (s *Server) Setup() {
 			"message": fmt.Sprintf("Successfully %s %s/%s", actionType, kind, req.Name),
 			"output":  output,
 		})
-	})
+	}, s.mutating("flux.suspend"))
 
 	// Add endpoint for approving Terraform plans (Flux Tofu Controller) (context-aware)
 	s.echo.POST("/api/:context/flux/approve", func(c echo.Context) error {
@@ -845,7 +889,7 @@ func (s *Server) Setup() {
 		return c.JSON(http.StatusOK, map[string]string{
 			"message": fmt.Sprintf("Successfully approved plan for Terraform %s/%s", req.Namespace, req.Name),
 		})
-	})
+	}, s.mutating("flux.approve"))
 
 	// Add endpoint for diffing Flux Kustomization resources (context-aware)
 	s.echo.POST("/api/:context/flux/diff", func(c echo.Context) error {
@@ -856,6 +900,7 @@ func (s *Server) Setup() {
 		var req struct {
 			Name      string `json:"name"`
 			Namespace string `json:"namespace"`
+			Recursive bool   `json:"recursive"` // also diff the Kustomizations it applies, grouped per Kustomization
 		}
 		if err := c.Bind(&req); err != nil {
 			return c.JSON(http.StatusBadRequest, map[string]string{
@@ -873,6 +918,19 @@ func (s *Server) Setup() {
 		clientset := proxy.k8sClient.Clientset
 		ctx := context.Background()
 
+		if req.Recursive {
+			kustomizationDiffs, err := s.DiffKustomizationRecursive(ctx, proxy.k8sClient, req.Namespace, req.Name)
+			if err != nil {
+				log.Printf("Error generating recursive FluxCD-style diff: %v", err)
+				return c.JSON(http.StatusInternalServerError, map[string]string{
+					"error": fmt.Sprintf("Failed to generate FluxCD-style diff: %v", err),
+				})
+			}
+			return c.JSON(http.StatusOK, map[string]interface{}{
+				"kustomizations": kustomizationDiffs,
+			})
+		}
+
 		// Discover Flux API path for Kustomization
 		kustomizationAPIPath, err := proxy.getFluxAPIPath(ctx, "Kustomization")
 		if err != nil {
@@ -918,6 +976,119 @@ func (s *Server) Setup() {
 		})
 	})
 
+	// Add endpoint for diffing a Kustomization against an uploaded local checkout of its source (context-aware).
+	// The request body is a tar.gz of the source root, e.g. `tar czf - .` in the repository.
+	s.echo.POST("/api/:context/flux/diff/local/:namespace/:name", func(c echo.Context) error {
+		proxy, ok := getProxyFromContext(c)
+		if !ok {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "missing proxy in context"})
+		}
+
+		namespace := c.Param("namespace")
+		name := c.Param("name")
+
+		archive, err := io.ReadAll(io.LimitReader(c.Request().Body, MaxLocalSourceSize+1))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": fmt.Sprintf("Failed to read local source: %v", err),
+			})
+		}
+		if len(archive) == 0 {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "A tar.gz of the local source is required as the request body",
+			})
+		}
+		if len(archive) > MaxLocalSourceSize {
+			return c.JSON(http.StatusRequestEntityTooLarge, map[string]string{
+				"error": fmt.Sprintf("Local source is larger than %d MiB", MaxLocalSourceSize>>20),
+			})
+		}
+
+		fluxDiffResult, err := s.DiffKustomizationWithLocalSource(c.Request().Context(), proxy.k8sClient, namespace, name, archive)
+		if err != nil {
+			log.Printf("Error generating FluxCD-style diff from local source: %v", err)
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": fmt.Sprintf("Failed to generate FluxCD-style diff: %v", err),
+			})
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"fluxResult": fluxDiffResult,
+		})
+	})
+
+	// Add endpoint for diffing Flux HelmRelease resources against their rendered chart (context-aware)
+	s.echo.POST("/api/:context/flux/diff/helmrelease", func(c echo.Context) error {
+		proxy, ok := getProxyFromContext(c)
+		if !ok {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "missing proxy in context"})
+		}
+		var req struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		}
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid request body",
+			})
+		}
+
+		// Verify required fields
+		if req.Name == "" || req.Namespace == "" {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Name, and namespace are required fields",
+			})
+		}
+
+		hc, err := proxy.HelmClient()
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": err.Error(),
+			})
+		}
+
+		fluxDiffResult, err := s.DiffHelmRelease(c.Request().Context(), proxy.k8sClient, hc, req.Namespace, req.Name)
+		if err != nil {
+			log.Printf("Error generating HelmRelease diff: %v", err)
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": fmt.Sprintf("Failed to generate HelmRelease diff: %v", err),
+			})
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"fluxResult": fluxDiffResult,
+		})
+	})
+
+	// Add endpoint for the effective values of a Flux HelmRelease, with the source of every value
+	// and how it compares to the values of the deployed release
+	s.echo.GET("/api/:context/flux/helmrelease/:namespace/:name/values", func(c echo.Context) error {
+		proxy, ok := getProxyFromContext(c)
+		if !ok {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "missing proxy in context"})
+		}
+
+		namespace := c.Param("namespace")
+		name := c.Param("name")
+
+		hc, err := proxy.HelmClient()
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": err.Error(),
+			})
+		}
+
+		values, err := s.HelmReleaseEffectiveValues(c.Request().Context(), proxy.k8sClient, hc, namespace, name)
+		if err != nil {
+			log.Printf("Error resolving HelmRelease values: %v", err)
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": fmt.Sprintf("Failed to resolve HelmRelease values: %v", err),
+			})
+		}
+
+		return c.JSON(http.StatusOK, values)
+	})
+
 	// Add endpoint for inspecting Flux source artifacts (GitRepository, OCIRepository, Bucket, etc.)
 	// This downloads the artifact from source-controller (using port-forward when needed),
 	// extracts it to a temporary directory, walks the files, and returns a lightweight file listing.
@@ -1119,7 +1290,7 @@ func (s *Server) Setup() {
 			"message": fmt.Sprintf("Successfully scaled %s/%s to %d replicas", kind, req.Name, req.Replicas),
 			"output":  output,
 		})
-	})
+	}, s.mutating("scale"))
 
 	// Add endpoint for rollout restart of Kubernetes resources (context-aware)
 	s.echo.POST("/api/:context/rollout-restart", func(c echo.Context) error {
@@ -1231,7 +1402,7 @@ func (s *Server) Setup() {
 			"message": fmt.Sprintf("Successfully restarted rollout for %s/%s", kind, req.Name),
 			"output":  output,
 		})
-	})
+	}, s.mutating("rollout-restart"))
 
 	// Add endpoint for running a CronJob immediately by creating a one-off Job (context-aware)
 	// Equivalent to: kubectl create job --from=cronjob/<name> <generated-name>
@@ -1298,7 +1469,7 @@ func (s *Server) Setup() {
 		return c.JSON(http.StatusOK, map[string]string{
 			"message": fmt.Sprintf("Job %s created from CronJob %s/%s", created.Name, req.Namespace, req.Name),
 		})
-	})
+	}, s.mutating("cronjob.run"))
 
 	// Add endpoint for describing Kubernetes resources using kubectl describe (context-aware)
 	s.echo.GET("/api/:context/describe/:namespace/:kind/:name", func(c echo.Context) error {
@@ -1336,10 +1507,10 @@ func (s *Server) Setup() {
 		namespace := c.Param("namespace")
 		name := c.Param("name")
 
-		hc, err := helm.NewClient(proxy.k8sClient.Config, "")
+		hc, err := proxy.HelmClient()
 		if err != nil {
 			return c.JSON(http.StatusInternalServerError, map[string]string{
-				"error": fmt.Sprintf("failed to create helm client: %v", err),
+				"error": err.Error(),
 			})
 		}
 
@@ -1380,10 +1551,10 @@ func (s *Server) Setup() {
 			}
 		}
 
-		hc, err := helm.NewClient(proxy.k8sClient.Config, "")
+		hc, err := proxy.HelmClient()
 		if err != nil {
 			return c.JSON(http.StatusInternalServerError, map[string]string{
-				"error": fmt.Sprintf("failed to create helm client: %v", err),
+				"error": err.Error(),
 			})
 		}
 
@@ -1418,10 +1589,10 @@ func (s *Server) Setup() {
 			}
 		}
 
-		hc, err := helm.NewClient(proxy.k8sClient.Config, "")
+		hc, err := proxy.HelmClient()
 		if err != nil {
 			return c.JSON(http.StatusInternalServerError, map[string]string{
-				"error": fmt.Sprintf("failed to create helm client: %v", err),
+				"error": err.Error(),
 			})
 		}
 
@@ -1438,6 +1609,152 @@ func (s *Server) Setup() {
 		})
 	})
 
+	// Add endpoint for the objects of a Helm release revision with their live status (context-aware)
+	s.echo.GET("/api/:context/helm/resources/:namespace/:name", func(c echo.Context) error {
+		proxy, ok := getProxyFromContext(c)
+		if !ok {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "missing proxy in context"})
+		}
+
+		namespace := c.Param("namespace")
+		name := c.Param("name")
+
+		// Parse the revision query parameter if provided
+		revision := 0
+		if revStr := c.QueryParam("revision"); revStr != "" {
+			if rev, err := strconv.Atoi(revStr); err == nil && rev > 0 {
+				revision = rev
+			}
+		}
+
+		hc, err := proxy.HelmClient()
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": err.Error(),
+			})
+		}
+
+		resources, err := hc.GetResources(c.Request().Context(), name, namespace, revision)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": fmt.Sprintf("Failed to get Helm release resources: %v", err),
+			})
+		}
+
+		return c.JSON(http.StatusOK, resources)
+	})
+
+	// Add endpoint for the drift of the objects of a Helm release from its manifest (context-aware)
+	s.echo.GET("/api/:context/helm/drift/:namespace/:name", func(c echo.Context) error {
+		proxy, ok := getProxyFromContext(c)
+		if !ok {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "missing proxy in context"})
+		}
+
+		namespace := c.Param("namespace")
+		name := c.Param("name")
+
+		// Parse the revision query parameter if provided
+		revision := 0
+		if revStr := c.QueryParam("revision"); revStr != "" {
+			if rev, err := strconv.Atoi(revStr); err == nil && rev > 0 {
+				revision = rev
+			}
+		}
+
+		hc, err := proxy.HelmClient()
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": err.Error(),
+			})
+		}
+
+		driftResult, err := s.diffHelmRelease(c.Request().Context(), proxy.k8sClient, hc, name, namespace, revision)
+		if err != nil {
+			log.Printf("Error detecting Helm release drift: %v", err)
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": fmt.Sprintf("Failed to detect Helm release drift: %v", err),
+			})
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"fluxResult": driftResult,
+		})
+	})
+
+	// Add endpoint for the chart stored with a Helm release revision (context-aware)
+	s.echo.GET("/api/:context/helm/chart/:namespace/:name", func(c echo.Context) error {
+		proxy, ok := getProxyFromContext(c)
+		if !ok {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "missing proxy in context"})
+		}
+
+		namespace := c.Param("namespace")
+		name := c.Param("name")
+
+		// Parse the revision query parameter if provided
+		revision := 0
+		if revStr := c.QueryParam("revision"); revStr != "" {
+			if rev, err := strconv.Atoi(revStr); err == nil && rev > 0 {
+				revision = rev
+			}
+		}
+
+		hc, err := proxy.HelmClient()
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": err.Error(),
+			})
+		}
+
+		chart, err := hc.GetChart(c.Request().Context(), name, namespace, revision)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": fmt.Sprintf("Failed to get Helm chart: %v", err),
+			})
+		}
+
+		return c.JSON(http.StatusOK, chart)
+	})
+
+	// Add endpoint for the template sources of the chart of a Helm release revision (context-aware).
+	// ?file=templates/deployment.yaml returns a single template.
+	s.echo.GET("/api/:context/helm/chart/:namespace/:name/templates", func(c echo.Context) error {
+		proxy, ok := getProxyFromContext(c)
+		if !ok {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "missing proxy in context"})
+		}
+
+		namespace := c.Param("namespace")
+		name := c.Param("name")
+
+		// Parse the revision query parameter if provided
+		revision := 0
+		if revStr := c.QueryParam("revision"); revStr != "" {
+			if rev, err := strconv.Atoi(revStr); err == nil && rev > 0 {
+				revision = rev
+			}
+		}
+
+		hc, err := proxy.HelmClient()
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": err.Error(),
+			})
+		}
+
+		templates, err := hc.GetChartTemplates(c.Request().Context(), name, namespace, revision, c.QueryParam("file"))
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": fmt.Sprintf("Failed to get Helm chart templates: %v", err),
+			})
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"templates": templates,
+		})
+	})
+
 	// Add endpoints for listing Helm releases (context-aware) with optional Table response
 	// Support trailing resource segment to match client list path construction
 	s.echo.GET("/api/:context/helm/releases/releases", func(c echo.Context) error {
@@ -1502,24 +1819,312 @@ func (s *Server) Setup() {
 			})
 		}
 
-		hc, err := helm.NewClient(proxy.k8sClient.Config, "")
+		hc, err := proxy.HelmClient()
 		if err != nil {
 			return c.JSON(http.StatusInternalServerError, map[string]string{
-				"error": fmt.Sprintf("failed to create helm client: %v", err),
+				"error": err.Error(),
 			})
 		}
 
-		// Perform the rollback
-		err = hc.Rollback(c.Request().Context(), name, namespace, revision)
+		var req helmJobOptions
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid request body",
+			})
+		}
+		timeout, err := req.timeout()
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+		}
+		opts := helm.RollbackOptions{
+			Force:         req.Force,
+			CleanupOnFail: req.CleanupOnFail,
+			DisableHooks:  req.NoHooks,
+			Timeout:       timeout,
+		}
+
+		// The rollback waits for the release to become ready, which takes longer than
+		// an HTTP request should. It runs as a job, its progress is streamed over the WebSocket.
+		job, err := proxy.helmJobs.Start(helm.Job{
+			Action:    "rollback",
+			Namespace: namespace,
+			Release:   name,
+			Revision:  revision,
+		}, func(report func(helm.JobEvent)) error {
+			return hc.Rollback(context.Background(), name, namespace, revision, opts, report)
+		}, s.auditJob(c, "helm.rollback"))
 		if err != nil {
 			return c.JSON(http.StatusInternalServerError, map[string]string{
-				"error": fmt.Sprintf("Failed to rollback Helm release: %v", err),
+				"error": err.Error(),
 			})
 		}
 
-		return c.JSON(http.StatusOK, map[string]string{
-			"message": fmt.Sprintf("Successfully rolled back %s to revision %d", name, revision),
-		})
+		return c.JSON(http.StatusAccepted, job)
+	}, s.mutating("helm.rollback"))
+
+	// Dry-run of a Helm upgrade with edited values, rendered from the chart stored in the release
+	s.echo.POST("/api/:context/helm/upgrade/:namespace/:name/preview", func(c echo.Context) error {
+		proxy, ok := getProxyFromContext(c)
+		if !ok {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "missing proxy in context"})
+		}
+
+		var req helm.UpgradeValues
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid request body",
+			})
+		}
+
+		hc, err := proxy.HelmClient()
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": err.Error(),
+			})
+		}
+
+		preview, err := hc.PreviewUpgrade(c.Request().Context(), c.Param("name"), c.Param("namespace"), req)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": fmt.Sprintf("Failed to preview Helm upgrade: %v", err),
+			})
+		}
+		return c.JSON(http.StatusOK, preview)
+	})
+
+	// Helm upgrade with edited values, after a preview. revision is the revision the preview
+	// was rendered against, the upgrade is refused if the release changed since.
+	s.echo.POST("/api/:context/helm/upgrade/:namespace/:name", func(c echo.Context) error {
+		proxy, ok := getProxyFromContext(c)
+		if !ok {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "missing proxy in context"})
+		}
+
+		namespace := c.Param("namespace")
+		name := c.Param("name")
+
+		var req struct {
+			helm.UpgradeValues
+			helmJobOptions
+			Revision int `json:"revision"`
+		}
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid request body",
+			})
+		}
+		if req.Revision <= 0 {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "revision of the previewed release is required",
+			})
+		}
+		timeout, err := req.timeout()
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+		}
+		opts := helm.UpgradeOptions{
+			Force:         req.Force,
+			CleanupOnFail: req.CleanupOnFail,
+			DisableHooks:  req.NoHooks,
+			Timeout:       timeout,
+		}
+
+		hc, err := proxy.HelmClient()
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": err.Error(),
+			})
+		}
+
+		job, err := proxy.helmJobs.Start(helm.Job{
+			Action:    "upgrade",
+			Namespace: namespace,
+			Release:   name,
+			Revision:  req.Revision,
+		}, func(report func(helm.JobEvent)) error {
+			return hc.Upgrade(context.Background(), name, namespace, req.Revision, req.UpgradeValues, opts, report)
+		}, s.auditJob(c, "helm.upgrade"))
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusAccepted, job)
+	}, s.mutating("helm.upgrade"))
+
+	// What uninstalling a Helm release would delete, keep and run. Uninstalling requires the token it returns.
+	s.echo.GET("/api/:context/helm/uninstall/:namespace/:name/preview", func(c echo.Context) error {
+		proxy, ok := getProxyFromContext(c)
+		if !ok {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "missing proxy in context"})
+		}
+
+		hc, err := proxy.HelmClient()
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": err.Error(),
+			})
+		}
+
+		preview, err := hc.PreviewUninstall(c.Request().Context(), c.Param("name"), c.Param("namespace"))
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": fmt.Sprintf("Failed to preview Helm uninstall: %v", err),
+			})
+		}
+		return c.JSON(http.StatusOK, preview)
+	})
+
+	// Helm uninstall, after a preview. token is the token of the preview,
+	// the uninstall is refused if the release changed since.
+	s.echo.POST("/api/:context/helm/uninstall/:namespace/:name", func(c echo.Context) error {
+		proxy, ok := getProxyFromContext(c)
+		if !ok {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "missing proxy in context"})
+		}
+
+		namespace := c.Param("namespace")
+		name := c.Param("name")
+
+		var req struct {
+			helmJobOptions
+			KeepHistory bool   `json:"keepHistory"`
+			Token       string `json:"token"`
+		}
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid request body",
+			})
+		}
+		if req.Token == "" {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "token of the uninstall preview is required",
+			})
+		}
+		timeout, err := req.timeout()
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+		}
+		opts := helm.UninstallOptions{
+			KeepHistory:  req.KeepHistory,
+			DisableHooks: req.NoHooks,
+			Timeout:      timeout,
+		}
+
+		hc, err := proxy.HelmClient()
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": err.Error(),
+			})
+		}
+
+		job, err := proxy.helmJobs.Start(helm.Job{
+			Action:    "uninstall",
+			Namespace: namespace,
+			Release:   name,
+		}, func(report func(helm.JobEvent)) error {
+			return hc.Uninstall(context.Background(), name, namespace, req.Token, opts, report)
+		}, s.auditJob(c, "helm.uninstall"))
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusAccepted, job)
+	}, s.mutating("helm.uninstall"))
+
+	// Results of the last run of the tests of a Helm release (context-aware)
+	s.echo.GET("/api/:context/helm/tests/:namespace/:name", func(c echo.Context) error {
+		proxy, ok := getProxyFromContext(c)
+		if !ok {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "missing proxy in context"})
+		}
+
+		hc, err := proxy.HelmClient()
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": err.Error(),
+			})
+		}
+
+		results, err := hc.GetTestResults(c.Request().Context(), c.Param("name"), c.Param("namespace"))
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": fmt.Sprintf("Failed to get Helm test results: %v", err),
+			})
+		}
+		return c.JSON(http.StatusOK, results)
+	})
+
+	// Run the tests of a Helm release, like `helm test`. The test pods' phases and logs are streamed over the WebSocket.
+	s.echo.POST("/api/:context/helm/tests/:namespace/:name", func(c echo.Context) error {
+		proxy, ok := getProxyFromContext(c)
+		if !ok {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "missing proxy in context"})
+		}
+
+		namespace := c.Param("namespace")
+		name := c.Param("name")
+
+		var req helmJobOptions
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid request body",
+			})
+		}
+		timeout, err := req.timeout()
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+		}
+		opts := helm.TestOptions{Timeout: timeout}
+
+		hc, err := proxy.HelmClient()
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": err.Error(),
+			})
+		}
+
+		job, err := proxy.helmJobs.Start(helm.Job{
+			Action:    "test",
+			Namespace: namespace,
+			Release:   name,
+		}, func(report func(helm.JobEvent)) error {
+			return hc.Test(context.Background(), name, namespace, opts, report)
+		}, s.auditJob(c, "helm.test"))
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusAccepted, job)
+	}, s.mutating("helm.test"))
+
+	// Status of a background Helm job, like a rollback
+	s.echo.GET("/api/:context/helm/jobs/:id", func(c echo.Context) error {
+		proxy, ok := getProxyFromContext(c)
+		if !ok {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "missing proxy in context"})
+		}
+
+		job, ok := proxy.helmJobs.Get(c.Param("id"))
+		if !ok {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": fmt.Sprintf("Helm job %s not found", c.Param("id")),
+			})
+		}
+		return c.JSON(http.StatusOK, job)
 	})
 
 	// Kubernetes API proxy endpoints
@@ -1529,8 +2134,13 @@ func (s *Server) Setup() {
 		if !ok {
 			return c.JSON(http.StatusBadRequest, map[string]string{"error": "missing proxy in context"})
 		}
+		if s.config.ReadOnly {
+			if err := checkReadOnlyProxyRequest(c.Request(), c.Param("*")); err != nil {
+				return c.JSON(http.StatusForbidden, map[string]string{"error": err.Error()})
+			}
+		}
 		return proxy.HandleAPIRequest(c)
-	})
+	}, s.auditProxyWrites)
 
 	// Add endpoint for kubectl exec WebSocket connections with context
 	s.echo.GET("/api/:context/exec/:namespace/:podname", func(c echo.Context) error {
@@ -1540,7 +2150,7 @@ func (s *Server) Setup() {
 		}
 
 		return s.handleExecWebSocketWithClient(c, proxy.k8sClient)
-	})
+	}, s.mutating("exec"))
 
 	// Add endpoint for node debug (similar to kubectl debug node)
 	s.echo.POST("/api/:context/node-debug/:nodename", func(c echo.Context) error {
@@ -1550,7 +2160,7 @@ func (s *Server) Setup() {
 		}
 
 		return s.handleNodeDebugCreate(c, proxy.k8sClient)
-	})
+	}, s.mutating("node-debug"))
 
 	// Health check endpoint
 	s.echo.GET("/healthz", func(c echo.Context) error {
@@ -1576,7 +2186,7 @@ func (s *Server) getOrCreateK8sProxyForContext(contextName string) (*KubernetesP
 	}
 
 	// Create proxy for this context
-	proxy, err := NewKubernetesProxy(client, s.config.AccessLogEnabled)
+	proxy, err := NewKubernetesProxy(client, s.config.AccessLogEnabled, helmStorageFor(s.config, contextName))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create proxy for context '%s': %w", contextName, err)
 	}
@@ -1596,12 +2206,35 @@ func (s *Server) getOrCreateK8sProxyForContext(contextName string) (*KubernetesP
 	return proxy, nil
 }
 
+// k8sClientForContext returns the Kubernetes client of the cached proxy for the given context
+func (s *Server) k8sClientForContext(contextName string) (*kubernetes.Client, error) {
+	proxy, err := s.getOrCreateK8sProxyForContext(contextName)
+	if err != nil {
+		return nil, err
+	}
+	return proxy.k8sClient, nil
+}
+
+// contextNames returns the names of every context in the kubeconfig, sorted
+func (s *Server) contextNames() ([]string, error) {
+	tmpClient, err := kubernetes.NewClient(s.config.KubeConfigPath, s.config.InsecureSkipTLSVerify, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+	names := make([]string, 0, len(tmpClient.AvailableContexts))
+	for name := range tmpClient.AvailableContexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
 // handleHelmReleasesList lists Helm releases and returns either a Kubernetes Table or a plain List
 func (s *Server) handleHelmReleasesList(c echo.Context, proxy *KubernetesProxy, namespace string) error {
-	hc, err := helm.NewClient(proxy.k8sClient.Config, "")
+	hc, err := proxy.HelmClient()
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": fmt.Sprintf("failed to create helm client: %v", err),
+			"error": err.Error(),
 		})
 	}
 
@@ -1664,13 +2297,7 @@ func (s *Server) handleKluctlDeploymentsList(c echo.Context, proxy *KubernetesPr
 		if namespace != "" && obj.Metadata.Namespace != namespace {
 			continue
 		}
-		item := map[string]interface{}{
-			"apiVersion": obj.APIVersion,
-			"kind":       obj.Kind,
-			"metadata":   obj.Metadata,
-			"spec":       obj.Spec,
-			"status":     obj.Status,
-		}
+		item := kluctlDeploymentWireObject(obj)
 		items = append(items, item)
 		rows = append(rows, map[string]interface{}{
 			"cells": []interface{}{
@@ -1904,9 +2531,20 @@ func DownloadAndExtractArtifact(ctx context.Context, client *kubernetes.Client,
 	return tempDir, nil
 }
 
+// Limits of what ExtractTarGz extracts, so a small archive can't fill the disk
+const (
+	maxExtractedSize    = 1 << 30 // bytes of file content
+	maxExtractedEntries = 100000  // files and directories
+)
+
 // ExtractTarGz extracts a tar.gz archive to the specified directory.
 // Returns the number of files/directories extracted.
 func ExtractTarGz(data []byte, destDir string) (int, error) {
+	return extractTarGz(data, destDir, maxExtractedSize, maxExtractedEntries)
+}
+
+// extractTarGz extracts a tar.gz archive like ExtractTarGz, with custom limits
+func extractTarGz(data []byte, destDir string, maxSize int64, maxEntries int) (int, error) {
 	// Create a gzip reader
 	gzReader, err := gzip.NewReader(bytes.NewReader(data))
 	if err != nil {
@@ -1918,6 +2556,8 @@ func ExtractTarGz(data []byte, destDir string) (int, error) {
 	tarReader := tar.NewReader(gzReader)
 
 	extractedCount := 0
+	var extractedSize int64
+	skipped := 0
 
 	// Extract files
 	for {
@@ -1933,6 +2573,9 @@ func ExtractTarGz(data []byte, destDir string) (int, error) {
 		if header.Name == "." {
 			continue
 		}
+		if extractedCount >= maxEntries {
+			return extractedCount, fmt.Errorf("archive has more than %d files and directories", maxEntries)
+		}
 
 		// Construct the full path
 		path := filepath.Join(destDir, header.Name)
@@ -1945,15 +2588,18 @@ func ExtractTarGz(data []byte, destDir string) (int, error) {
 		switch header.Typeflag {
 		case tar.TypeDir:
 			// Create directory
-			log.Printf("Extracting directory: %s", header.Name)
 			err := os.MkdirAll(path, 0o755)
 			if err != nil {
 				return extractedCount, fmt.Errorf("failed to create directory %s: %w", path, err)
 			}
 			extractedCount++
 		case tar.TypeReg:
+			extractedSize += header.Size
+			if extractedSize > maxSize {
+				return extractedCount, fmt.Errorf("archive is larger than %d MiB uncompressed", maxSize>>20)
+			}
+
 			// Create file
-			log.Printf("Extracting file: %s (%d bytes)", header.Name, header.Size)
 			err := os.MkdirAll(filepath.Dir(path), 0o755)
 			if err != nil {
 				return extractedCount, fmt.Errorf("failed to create parent directory for %s: %w", path, err)
@@ -1964,6 +2610,7 @@ func ExtractTarGz(data []byte, destDir string) (int, error) {
 				return extractedCount, fmt.Errorf("failed to create file %s: %w", path, err)
 			}
 
+			// The tar reader stops at the size in the header
 			_, err = io.Copy(file, tarReader)
 			file.Close()
 			if err != nil {
@@ -1971,10 +2618,13 @@ func ExtractTarGz(data []byte, destDir string) (int, error) {
 			}
 			extractedCount++
 		default:
-			log.Printf("Skipping unsupported tar entry type %d for %s", header.Typeflag, header.Name)
+			skipped++
 		}
 	}
 
+	if skipped > 0 {
+		log.Printf("Skipped %d unsupported tar entries, like symlinks", skipped)
+	}
 	return extractedCount, nil
 }
 
@@ -2031,7 +2681,7 @@ func ListArtifactFiles(root string) ([]map[string]interface{}, error) {
 // Helper function to discover Flux API path for a resource kind using a client
 func (s *Server) discoverFluxAPIPathForClient(ctx context.Context, client *kubernetes.Client, kind string) (string, error) {
 	// Create a temporary proxy for discovery (or we could extract discovery logic)
-	proxy, err := NewKubernetesProxy(client, s.config.AccessLogEnabled)
+	proxy, err := NewKubernetesProxy(client, s.config.AccessLogEnabled, helmStorageFor(s.config, client.CurrentContext))
 	if err != nil {
 		return "", fmt.Errorf("failed to create proxy for discovery: %w", err)
 	}
@@ -2223,7 +2873,11 @@ func (s *Server) Start() error {
 
 // Shutdown gracefully shuts down the server
 func (s *Server) Shutdown(ctx context.Context) error {
-	return s.echo.Shutdown(ctx)
+	err := s.echo.Shutdown(ctx)
+	if closeErr := s.audit.Close(); closeErr != nil {
+		log.Printf("Error closing audit log: %v", closeErr)
+	}
+	return err
 }
 
 // SetupSourceControllerPortForward sets up port-forwarding to the source-controller
@@ -2334,9 +2988,7 @@ func (s *Server) handleExecWebSocketWithClient(c echo.Context, client *kubernete
 	log.Printf("Setting up exec WebSocket for pod %s/%s with auto shell detection (context: %s, container: %s)", namespace, podname, client.CurrentContext, requestedContainer)
 
 	upgrader := websocket.Upgrader{
-		CheckOrigin: func(r *http.Request) bool {
-			return true
-		},
+		CheckOrigin: sameOrigin,
 	}
 
 	ws, err := upgrader.Upgrade(c.Response(), c.Request(), nil)
@@ -2651,6 +3303,37 @@ func (s *Server) handleExecWebSocketWithClient(c echo.Context, client *kubernete
 	return nil
 }
 
+// DiffKustomization loads the named Kustomization from the cluster and runs the same
+// FluxCD-style diff pipeline that backs the /api/:context/flux/diff endpoint.
+// It does not need the HTTP server to be started, so it can be used by headless callers such as the CLI.
+func (s *Server) DiffKustomization(ctx context.Context, client *kubernetes.Client, namespace, name string) ([]FluxDiffResult, error) {
+	kustomization, err := s.getKustomization(ctx, client, name, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Kustomization %s/%s: %w", namespace, name, err)
+	}
+
+	return s.generateKustomizationDiffWithFluxStyle(ctx, client, kustomization)
+}
+
+// getKustomization fetches a Flux Kustomization and decodes it into the typed API object
+func (s *Server) getKustomization(ctx context.Context, client *kubernetes.Client, name, namespace string) (*kustomizev1.Kustomization, error) {
+	apiPath, err := s.discoverFluxAPIPathForClient(ctx, client, "Kustomization")
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover Kustomization API path: %w", err)
+	}
+	path := fmt.Sprintf(apiPath, namespace, name)
+	data, err := client.Clientset.RESTClient().Get().AbsPath(path).DoRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var kustomization kustomizev1.Kustomization
+	if err := json.Unmarshal(data, &kustomization); err != nil {
+		return nil, fmt.Errorf("failed to parse Kustomization resource: %w", err)
+	}
+	return &kustomization, nil
+}
+
 // generateKustomizationDiffWithFluxStyle generates a diff using the actual FluxCD Builder and Diff functionality
 func (s *Server) generateKustomizationDiffWithFluxStyle(ctx context.Context, client *kubernetes.Client, kustomization *kustomizev1.Kustomization) ([]FluxDiffResult, error) {
 	log.Printf("Generating FluxCD diff for Kustomization %s/%s using actual FluxCD Builder",
@@ -2670,41 +3353,66 @@ func (s *Server) generateKustomizationDiffWithFluxStyle(ctx context.Context, cli
 	}
 	defer os.RemoveAll(tempDir)
 
-	// Step 2: Create ConfigFlags from our Kubernetes client
-	configFlags := &genericclioptions.ConfigFlags{
-		APIServer:   &client.Config.Host,
-		BearerToken: &client.Config.BearerToken,
-		Context:     &client.CurrentContext,
+	return s.diffKustomizationSource(client, kustomization, tempDir)
+}
+
+// MaxLocalSourceSize is the size limit of a local source archive uploaded for a Kustomization diff
+const MaxLocalSourceSize = 256 << 20
+
+// DiffKustomizationWithLocalSource diffs a Kustomization like DiffKustomization, but builds it from archive,
+// a tar.gz of a local checkout of its source, instead of the source artifact in the cluster.
+// The archive is the root of the source, Spec.Path of the Kustomization is resolved in it.
+func (s *Server) DiffKustomizationWithLocalSource(ctx context.Context, client *kubernetes.Client, namespace, name string, archive []byte) ([]FluxDiffResult, error) {
+	kustomization, err := s.getKustomization(ctx, client, name, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Kustomization %s/%s: %w", namespace, name, err)
 	}
-	if client.Config.CAFile != "" {
-		configFlags.CAFile = &client.Config.CAFile
+	log.Printf("Generating FluxCD diff for Kustomization %s/%s from a local source", namespace, name)
+
+	tempDir, err := os.MkdirTemp("", "flux-local-source-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
 	}
-	configFlags.Insecure = &client.Config.Insecure
+	defer os.RemoveAll(tempDir)
 
-	// Set the kubeconfig path from the server's config
-	if s.config.KubeConfigPath != "" {
-		configFlags.KubeConfig = &s.config.KubeConfigPath
+	extractedCount, err := ExtractTarGz(archive, tempDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract local source: %w", err)
 	}
+	log.Printf("Extracted %d files/directories from local source", extractedCount)
 
-	namespace := kustomization.ObjectMeta.Namespace
-	configFlags.Namespace = &namespace
+	return s.diffKustomizationSource(client, kustomization, tempDir)
+}
 
-	// Step 3: Create FluxCD client options
-	clientOpts := &runclient.Options{
-		QPS:   100,
-		Burst: 300,
-	}
+// diffKustomizationSource builds a Kustomization from sourceDir, the extracted source it references, and diffs it
+// against the cluster. opts are added to the options of the builder.
+func (s *Server) diffKustomizationSource(client *kubernetes.Client, kustomization *kustomizev1.Kustomization, sourceDir string, opts ...build.BuilderOptionFunc) ([]FluxDiffResult, error) {
+	// Step 2 and 3: Create ConfigFlags and FluxCD client options from our Kubernetes client
+	configFlags, clientOpts := s.fluxClientConfig(client, kustomization.ObjectMeta.Namespace)
 
 	// Step 4: Build the resources path
-	resourcesPath := filepath.Join(tempDir, kustomization.Spec.Path)
+	resourcesPath := filepath.Join(sourceDir, kustomization.Spec.Path)
+	if !strings.HasPrefix(filepath.Clean(resourcesPath)+string(os.PathSeparator), filepath.Clean(sourceDir)+string(os.PathSeparator)) {
+		return nil, fmt.Errorf("path %s of Kustomization %s/%s is outside its source", kustomization.Spec.Path, kustomization.Namespace, kustomization.Name)
+	}
+	if _, err := os.Stat(resourcesPath); err != nil {
+		return nil, fmt.Errorf("path %s of Kustomization %s/%s not found in its source", kustomization.Spec.Path, kustomization.Namespace, kustomization.Name)
+	}
 
 	// Step 5: Create the FluxCD Builder with the exact same options FluxCD uses
+	sourceRef := kustomization.Spec.SourceRef.DeepCopy()
+	if sourceRef.Namespace == "" {
+		sourceRef.Namespace = kustomization.Namespace
+	}
 	builder, err := build.NewBuilder(
 		kustomization.ObjectMeta.Name,
 		resourcesPath,
-		build.WithClientConfig(configFlags, clientOpts),
-		build.WithNamespace(kustomization.ObjectMeta.Namespace),
-		build.WithTimeout(80*time.Second),
+		append([]build.BuilderOptionFunc{
+			build.WithClientConfig(configFlags, clientOpts),
+			build.WithNamespace(kustomization.ObjectMeta.Namespace),
+			build.WithTimeout(80 * time.Second),
+			build.WithLocalSources(map[string]string{sourceRef.String(): sourceDir}),
+		}, opts...)...,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create FluxCD builder: %w", err)
@@ -2724,6 +3432,31 @@ func (s *Server) generateKustomizationDiffWithFluxStyle(ctx context.Context, cli
 	return fluxDiffResult, nil
 }
 
+// fluxClientConfig returns the ConfigFlags and client options the FluxCD libraries need to reach the cluster of client
+func (s *Server) fluxClientConfig(client *kubernetes.Client, namespace string) (*genericclioptions.ConfigFlags, *runclient.Options) {
+	configFlags := &genericclioptions.ConfigFlags{
+		APIServer:   &client.Config.Host,
+		BearerToken: &client.Config.BearerToken,
+		Context:     &client.CurrentContext,
+	}
+	if client.Config.CAFile != "" {
+		configFlags.CAFile = &client.Config.CAFile
+	}
+	configFlags.Insecure = &client.Config.Insecure
+
+	// Set the kubeconfig path from the server's config
+	if s.config.KubeConfigPath != "" {
+		configFlags.KubeConfig = &s.config.KubeConfigPath
+	}
+	configFlags.Namespace = &namespace
+
+	clientOpts := &runclient.Options{
+		QPS:   100,
+		Burst: 300,
+	}
+	return configFlags, clientOpts
+}
+
 // getSourceArtifactDirectory downloads and extracts the source artifact, returning the temporary directory path
 func (s *Server) getSourceArtifactDirectory(ctx context.Context, client *kubernetes.Client, kustomization *kustomizev1.Kustomization, sourceNamespace string) (string, error) {
 	// Get the source resource to find the artifact
@@ -2746,6 +3479,22 @@ func (s *Server) getSourceArtifactDirectory(ctx context.Context, client *kuberne
 	}
 
 	// Extract artifact information
+	artifactURL, err := sourceArtifactURL(sourceResource)
+	if err != nil {
+		return "", err
+	}
+
+	// Download and extract the artifact
+	tempDir, err := DownloadAndExtractArtifact(ctx, client, artifactURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to download and extract artifact: %w", err)
+	}
+
+	return tempDir, nil
+}
+
+// sourceArtifactURL returns the URL of the artifact a Flux source published in its status
+func sourceArtifactURL(sourceResource map[string]interface{}) (string, error) {
 	status, ok := sourceResource["status"].(map[string]interface{})
 	if !ok {
 		return "", fmt.Errorf("source resource has no status")
@@ -2760,14 +3509,7 @@ func (s *Server) getSourceArtifactDirectory(ctx context.Context, client *kuberne
 	if !ok {
 		return "", fmt.Errorf("artifact has no URL")
 	}
-
-	// Download and extract the artifact
-	tempDir, err := DownloadAndExtractArtifact(ctx, client, artifactURL)
-	if err != nil {
-		return "", fmt.Errorf("failed to download and extract artifact: %w", err)
-	}
-
-	return tempDir, nil
+	return artifactURL, nil
 }
 
 // handleNodeDebugCreate creates a debug pod on a node (similar to kubectl debug node)
@@ -0,0 +1,99 @@
+// Copyright 2025 Laszlo Consulting Kft.
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"strings"
+	"testing"
+)
+
+type tarEntry struct {
+	name     string
+	typeflag byte
+	content  string
+}
+
+func tarGz(t *testing.T, entries []tarEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		header := &tar.Header{Name: e.name, Typeflag: e.typeflag, Mode: 0o644, Size: int64(len(e.content))}
+		if e.typeflag == tar.TypeDir {
+			header.Mode, header.Size = 0o755, 0
+		}
+		if e.typeflag == tar.TypeSymlink {
+			header.Linkname, header.Size = e.content, 0
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if e.typeflag == tar.TypeReg {
+			if _, err := tw.Write([]byte(e.content)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestExtractTarGz(t *testing.T) {
+	source := []tarEntry{
+		{name: "apps", typeflag: tar.TypeDir},
+		{name: "apps/web.yaml", typeflag: tar.TypeReg, content: "kind: Deployment\n"},
+		{name: "apps/db.yaml", typeflag: tar.TypeReg, content: "kind: StatefulSet\n"},
+	}
+
+	tests := []struct {
+		name       string
+		entries    []tarEntry
+		maxSize    int64
+		maxEntries int
+		wantCount  int
+		wantErr    string
+	}{
+		{name: "within the limits", entries: source, maxSize: 1 << 20, maxEntries: 3, wantCount: 3},
+		{
+			name:       "unsupported entries are skipped",
+			entries:    append([]tarEntry{{name: "link.yaml", typeflag: tar.TypeSymlink, content: "apps/web.yaml"}}, source...),
+			maxSize:    1 << 20,
+			maxEntries: 3,
+			wantCount:  3,
+		},
+		{name: "too many entries", entries: source, maxSize: 1 << 20, maxEntries: 2, wantErr: "more than 2 files and directories"},
+		{name: "too large", entries: source, maxSize: 20, maxEntries: 3, wantErr: "larger than 0 MiB uncompressed"},
+		{
+			name:       "path outside the destination",
+			entries:    []tarEntry{{name: "../escape.yaml", typeflag: tar.TypeReg, content: "kind: Secret\n"}},
+			maxSize:    1 << 20,
+			maxEntries: 3,
+			wantErr:    "invalid file path",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count, err := extractTarGz(tarGz(t, tt.entries), t.TempDir(), tt.maxSize, tt.maxEntries)
+			if tt.wantErr == "" {
+				if err != nil || count != tt.wantCount {
+					t.Fatalf("extractTarGz() = %d, %v, want %d", count, err, tt.wantCount)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("extractTarGz() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
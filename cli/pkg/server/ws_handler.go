@@ -8,7 +8,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
-	"net/http"
 	"os"
 	"sort"
 	"strings"
@@ -28,6 +27,8 @@ type ClientMessage struct {
 	Action string            `json:"action"` // subscribe or unsubscribe
 	Path   string            `json:"path"`
 	Params map[string]string `json:"params,omitempty"`
+	// Contexts to watch the path in, only on the /ws endpoint; "*" means every context in the kubeconfig
+	Contexts []string `json:"contexts,omitempty"`
 }
 
 // ServerMessage represents a message from the server
@@ -37,8 +38,17 @@ type ServerMessage struct {
 	Path  string                 `json:"path"`
 	Data  *kubernetes.WatchEvent `json:"data,omitempty"`
 	Error string                 `json:"error,omitempty"`
+	// Encoding is "patch" when Data.Object is an RFC 7386 merge patch against the last object
+	// sent for the same UID, instead of the full object. See wsutil.PatchEncoder.
+	Encoding string `json:"encoding,omitempty"`
+	// Context is the kubeconfig context the event comes from, on multi-context subscriptions
+	Context string `json:"context,omitempty"`
 }
 
+// watchBatchWindow is how long Secret events are collected before the Helm releases
+// or Kluctl deployments they make up are recomputed, see collectWatchBatch
+const watchBatchWindow = 100 * time.Millisecond
+
 // watchCfg stores per-watch configuration for a connection
 type watchCfg struct {
 	cancel context.CancelFunc
@@ -50,27 +60,51 @@ type WebSocketHandler struct {
 	upgrader         websocket.Upgrader
 	k8sClient        *kubernetes.Client
 	helmClient       *helm.Client
+	helmJobs         *helm.Jobs // background Helm actions whose progress can be subscribed to
+	watchMux         *kubernetes.WatchMultiplexer
 	accessLogEnabled bool
+	maxUpdateRate    float64 // default per-object MODIFIED events per second, see wsutil.CoalescingQueue
+
+	// Resolve the contexts of multi-context subscriptions, nil on connections bound to a context
+	clientForContext func(contextName string) (*kubernetes.Client, error)
+	contextNames     func() ([]string, error)
 
 	// Maps connection to a map of resource paths to contexts
 	// This allows us to cancel watches when clients unsubscribe
 	watchContexts sync.Map
 }
 
-// NewWebSocketHandler creates a new WebSocketHandler
-func NewWebSocketHandler(k8sClient *kubernetes.Client, helmClient *helm.Client, accessLogEnabled bool) *WebSocketHandler {
+// NewWebSocketHandler creates a new WebSocketHandler.
+// Kubernetes watches are shared with other connections through watchMux.
+// maxUpdateRate limits how often an object's updates are sent, subscriptions can override it with params.maxUpdateRate.
+func NewWebSocketHandler(k8sClient *kubernetes.Client, helmClient *helm.Client, watchMux *kubernetes.WatchMultiplexer, accessLogEnabled bool, maxUpdateRate float64) *WebSocketHandler {
 	return &WebSocketHandler{
 		upgrader: websocket.Upgrader{
-			CheckOrigin: func(r *http.Request) bool {
-				return true // Allow all origins
-			},
+			CheckOrigin: sameOrigin,
 		},
 		k8sClient:        k8sClient,
 		helmClient:       helmClient,
+		watchMux:         watchMux,
 		accessLogEnabled: accessLogEnabled,
+		maxUpdateRate:    maxUpdateRate,
 	}
 }
 
+// NewMultiContextWebSocketHandler creates a WebSocketHandler that isn't bound to a context.
+// Every subscription lists its contexts, clientForContext resolves them and contextNames expands "*".
+func NewMultiContextWebSocketHandler(
+	clientForContext func(contextName string) (*kubernetes.Client, error),
+	contextNames func() ([]string, error),
+	watchMux *kubernetes.WatchMultiplexer,
+	accessLogEnabled bool,
+	maxUpdateRate float64,
+) *WebSocketHandler {
+	h := NewWebSocketHandler(nil, nil, watchMux, accessLogEnabled, maxUpdateRate)
+	h.clientForContext = clientForContext
+	h.contextNames = contextNames
+	return h
+}
+
 // UpdateClients updates the Kubernetes and Helm clients when the context changes
 func (h *WebSocketHandler) UpdateClients(k8sClient *kubernetes.Client, helmClient *helm.Client) {
 	h.k8sClient = k8sClient
@@ -173,9 +207,28 @@ func (h *WebSocketHandler) handleSubscribe(
 		log.Printf("Subscribe request for path: %s", msg.Path)
 	}
 
-	// Build composite key from path and projection fields (if any)
+	// Build composite key from path, projection fields and filter (if any)
 	projFields := wsutil.ParseProjectionFields(msg.Params)
-	key := makeWatchKey(msg.Path, projFields)
+	filter, err := wsutil.ParseFilter(msg.Params)
+	if err != nil {
+		h.sendErrorMessage(ws, msg.ID, msg.Path, err.Error())
+		return
+	}
+	key := makeWatchKey(msg.Path, projFields, filter, msg.Contexts)
+
+	multiContext := len(msg.Contexts) > 0
+	if multiContext && h.clientForContext == nil {
+		h.sendErrorMessage(ws, msg.ID, msg.Path, "contexts can only be listed on the /ws endpoint")
+		return
+	}
+	if !multiContext && h.k8sClient == nil {
+		h.sendErrorMessage(ws, msg.ID, msg.Path, "contexts is required on the /ws endpoint")
+		return
+	}
+	if multiContext && (strings.Contains(msg.Path, "/api/helm/") || strings.Contains(msg.Path, "/api/kluctl/")) {
+		h.sendErrorMessage(ws, msg.ID, msg.Path, "multi-context subscriptions only support Kubernetes API paths")
+		return
+	}
 
 	// Check if already subscribed for this exact key
 	if _, exists := watchContextsForConn[key]; exists {
@@ -212,6 +265,13 @@ func (h *WebSocketHandler) handleSubscribe(
 		return
 	}
 
+	// Check if this is the progress of a background Helm job
+	if strings.Contains(msg.Path, "/api/helm/jobs/") {
+		h.handleHelmJobWatch(watchCtx, ws, msg)
+		h.sendStatusMessage(ws, msg.ID, msg.Path, "subscribed")
+		return
+	}
+
 	// Check if this is a Helm history path
 	if strings.Contains(msg.Path, "/api/helm/history") {
 		h.handleHelmHistoryWatch(watchCtx, ws, msg)
@@ -222,47 +282,134 @@ func (h *WebSocketHandler) handleSubscribe(
 		return
 	}
 
-	// Create channel for events
-	eventsChan := make(chan *kubernetes.WatchEvent, 100)
+	if multiContext {
+		h.subscribeContexts(watchCtx, ws, msg, key, watchIdsForConn, counters)
+		h.sendStatusMessage(ws, msg.ID, msg.Path, "subscribed")
+		return
+	}
 
-	// Start watching in a goroutine
-	go func() {
-		defer close(eventsChan)
-		defer watchCancel()
+	h.streamWatch(watchCtx, ws, msg, key, h.k8sClient, "", watchIdsForConn, counters, func(err error) {
+		log.Printf("Error watching resource [context=%s path=%s]: %v",
+			h.k8sClient.CurrentContext, msg.Path, err)
+		h.sendErrorMessage(ws, msg.ID, msg.Path,
+			fmt.Sprintf("error watching resource [context=%s]: %v",
+				h.k8sClient.CurrentContext, err))
+		delete(watchContextsForConn, key)
+		delete(watchIdsForConn, msg.ID)
+		watchCancel()
+	})
 
-		if err := h.k8sClient.WatchPath(watchCtx, msg.Path, eventsChan); err != nil {
-			if watchCtx.Err() == context.Canceled {
-				if h.accessLogEnabled {
-					log.Printf("Watch canceled for path: %s", msg.Path)
-				}
-				return // Context was cancelled, expected
+	// Send success message for standard K8s resources
+	h.sendStatusMessage(ws, msg.ID, msg.Path, "subscribed")
+}
+
+// subscribeContexts starts a watch of the path in every context of a multi-context subscription.
+// A context that can't be reached or whose watch ends is reported in an error message tagged
+// with the context; the other contexts keep streaming.
+func (h *WebSocketHandler) subscribeContexts(
+	watchCtx context.Context,
+	ws *wsutil.WebSocketConnection,
+	msg *ClientMessage,
+	key string,
+	watchIdsForConn map[string]string,
+	counters *wsutil.Counters,
+) {
+	contextNames, err := h.expandContexts(msg.Contexts)
+	if err != nil {
+		h.sendErrorMessage(ws, msg.ID, msg.Path, err.Error())
+		return
+	}
+
+	for _, contextName := range contextNames {
+		contextName := contextName
+		client, err := h.clientForContext(contextName)
+		if err != nil {
+			h.sendContextErrorMessage(ws, msg.ID, msg.Path, contextName, err.Error())
+			continue
+		}
+		h.streamWatch(watchCtx, ws, msg, key, client, contextName, watchIdsForConn, counters, func(err error) {
+			log.Printf("Error watching resource [context=%s path=%s]: %v", contextName, msg.Path, err)
+			h.sendContextErrorMessage(ws, msg.ID, msg.Path, contextName,
+				fmt.Sprintf("error watching resource [context=%s]: %v", contextName, err))
+		})
+	}
+}
+
+// expandContexts resolves "*" to every context of the kubeconfig and removes duplicates
+func (h *WebSocketHandler) expandContexts(requested []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var out []string
+	for _, name := range requested {
+		names := []string{name}
+		if name == "*" {
+			all, err := h.contextNames()
+			if err != nil {
+				return nil, fmt.Errorf("failed to list contexts: %w", err)
 			}
-			log.Printf("Error watching resource [context=%s path=%s]: %v",
-				h.k8sClient.CurrentContext, msg.Path, err)
-			h.sendErrorMessage(ws, msg.ID, msg.Path,
-				fmt.Sprintf("error watching resource [context=%s]: %v",
-					h.k8sClient.CurrentContext, err))
-			delete(watchContextsForConn, key)
-			delete(watchIdsForConn, msg.ID)
+			names = all
 		}
-	}()
+		for _, n := range names {
+			if n = strings.TrimSpace(n); n != "" && !seen[n] {
+				seen[n] = true
+				out = append(out, n)
+			}
+		}
+	}
+	return out, nil
+}
 
-	// Start sending events to client in a goroutine
+// streamWatch joins the shared watch of the path in the client's context and sends its events
+// to every id mapped to key. contextName tags the messages of multi-context subscriptions.
+// onEnd is called if the upstream watch ends while the subscription is still active.
+func (h *WebSocketHandler) streamWatch(
+	watchCtx context.Context,
+	ws *wsutil.WebSocketConnection,
+	msg *ClientMessage,
+	key string,
+	client *kubernetes.Client,
+	contextName string,
+	watchIdsForConn map[string]string,
+	counters *wsutil.Counters,
+	onEnd func(err error),
+) {
+	streamCtx, streamCancel := context.WithCancel(watchCtx)
+
+	// Every stream has its own filter, queue and encoder: objects of different contexts
+	// may share a namespace and name. The params were validated by handleSubscribe.
+	projFields := wsutil.ParseProjectionFields(msg.Params)
+	filter, _ := wsutil.ParseFilter(msg.Params)
+
+	// Join the shared watch for this context and path. Other connections watching the same
+	// path reuse the upstream watch; we get the cached objects first, then live events.
+	sub := h.watchMux.Subscribe(client, msg.Path)
+
+	// Events go through a coalescing queue, so a slow client never holds up the shared watch:
+	// while the client catches up, only the latest state of each object is kept.
+	queue := wsutil.NewCoalescingQueue(wsutil.ParseMaxUpdateRate(msg.Params, h.maxUpdateRate), counters)
+
+	// With params.encoding=patch, MODIFIED events carry a merge patch against the last object sent
+	var encoder *wsutil.PatchEncoder
+	if wsutil.ParsePatchEncoding(msg.Params) {
+		encoder = wsutil.NewPatchEncoder(wsutil.DefaultPatchResyncInterval)
+	}
+
+	// Move events from the shared watch into the queue
 	go func() {
+		defer sub.Close()
+		defer streamCancel()
+
 		for {
 			select {
-			case event, ok := <-eventsChan:
+			case event, ok := <-sub.Events():
 				if !ok {
-					log.Printf("Event channel closed for path: %s", msg.Path)
-					return
-				}
-				// Update counters and send to all ids mapped to this key
-				for id, mappedKey := range watchIdsForConn {
-					if mappedKey == key {
-						h.sendDataMessageWithCountersAndProject(ws, id, msg.Path, event, counters, projFields)
+					if streamCtx.Err() != nil {
+						return // Unsubscribed or connection closed, expected
 					}
+					onEnd(sub.Err())
+					return
 				}
-			case <-watchCtx.Done():
+				queue.Push(event)
+			case <-streamCtx.Done():
 				if h.accessLogEnabled {
 					log.Printf("Watch context done for path: %s", msg.Path)
 				}
@@ -271,8 +418,32 @@ func (h *WebSocketHandler) handleSubscribe(
 		}
 	}()
 
-	// Send success message for standard K8s resources
-	h.sendStatusMessage(ws, msg.ID, msg.Path, "subscribed")
+	// Start sending events to client in a goroutine
+	go func() {
+		for {
+			event, ok := queue.Pop(streamCtx)
+			if !ok {
+				return
+			}
+			transformed, removed := wsutil.TransformWatchEvent(event, projFields, filter)
+			if transformed == nil {
+				continue // Filtered out
+			}
+			encoding := ""
+			if encoder != nil {
+				var patched bool
+				if transformed, patched = encoder.Encode(transformed, time.Now()); patched {
+					encoding = wsutil.PatchEncoding
+				}
+			}
+			// Update counters and send to all ids mapped to this key
+			for id, mappedKey := range watchIdsForConn {
+				if mappedKey == key {
+					h.sendTransformedDataMessage(ws, id, msg.Path, contextName, transformed, encoding, removed, counters)
+				}
+			}
+		}
+	}()
 }
 
 // handleUnsubscribe handles an unsubscribe message
@@ -286,7 +457,8 @@ func (h *WebSocketHandler) handleUnsubscribe(
 	key, ok := watchIdsForConn[msg.ID]
 	if !ok || key == "" {
 		fields := wsutil.ParseProjectionFields(msg.Params)
-		key = makeWatchKey(msg.Path, fields)
+		filter, _ := wsutil.ParseFilter(msg.Params)
+		key = makeWatchKey(msg.Path, fields, filter, msg.Contexts)
 	}
 	// Check if subscribed
 	cfg, exists := watchContextsForConn[key]
@@ -317,15 +489,25 @@ func (h *WebSocketHandler) handleUnsubscribe(
 	h.sendStatusMessage(ws, msg.ID, msg.Path, "unsubscribed")
 }
 
-// makeWatchKey builds a stable composite key from path and projection fields
-func makeWatchKey(path string, fields []string) string {
-	if len(fields) == 0 {
-		return path
-	}
-	cp := make([]string, len(fields))
-	copy(cp, fields)
-	sort.Strings(cp)
-	return fmt.Sprintf("%s?fields=%s", path, strings.Join(cp, ","))
+// makeWatchKey builds a stable composite key from path, projection fields, filter and contexts
+func makeWatchKey(path string, fields []string, filter *wsutil.Filter, contexts []string) string {
+	key := path
+	if len(fields) > 0 {
+		cp := make([]string, len(fields))
+		copy(cp, fields)
+		sort.Strings(cp)
+		key = fmt.Sprintf("%s?fields=%s", path, strings.Join(cp, ","))
+	}
+	if filterKey := filter.Key(); filterKey != "" {
+		key = fmt.Sprintf("%s#%s", key, filterKey)
+	}
+	if len(contexts) > 0 {
+		cp := make([]string, len(contexts))
+		copy(cp, contexts)
+		sort.Strings(cp)
+		key = fmt.Sprintf("%s@%s", key, strings.Join(cp, ","))
+	}
+	return key
 }
 
 // sendDataMessage sends a data message to the client
@@ -349,19 +531,16 @@ func (h *WebSocketHandler) sendDataMessage(ws *wsutil.WebSocketConnection, id, p
 	h.sendMessage(ws, &msg)
 }
 
-// sendDataMessageWithCounters is like sendDataMessage but updates counters
-
-// sendDataMessageWithCountersAndProject is like sendDataMessageWithCounters but also applies
-// frontend-provided JSONPath-style projections when provided.
-func (h *WebSocketHandler) sendDataMessageWithCountersAndProject(ws *wsutil.WebSocketConnection, id, path string, data *kubernetes.WatchEvent, counters *wsutil.Counters, fields []string) {
-	transformed, removed := wsutil.TransformWatchEvent(data, fields)
+// sendTransformedDataMessage sends an event that already went through wsutil.TransformWatchEvent
+// (and possibly a wsutil.PatchEncoder) and updates the counters
+func (h *WebSocketHandler) sendTransformedDataMessage(ws *wsutil.WebSocketConnection, id, path, contextName string, transformed *kubernetes.WatchEvent, encoding string, removed int, counters *wsutil.Counters) {
 	if transformed != nil {
 		counters.AddObjects(1)
 	}
 	if removed > 0 {
 		counters.AddManagedBytes(int64(removed))
 	}
-	msg := ServerMessage{ID: id, Type: "data", Path: path, Data: transformed}
+	msg := ServerMessage{ID: id, Type: "data", Path: path, Data: transformed, Encoding: encoding, Context: contextName}
 	if err := wsutil.MarshalAndWrite(ws, &msg, counters); err != nil {
 		log.Printf("error writing message: %v", err)
 	}
@@ -369,11 +548,17 @@ func (h *WebSocketHandler) sendDataMessageWithCountersAndProject(ws *wsutil.WebS
 
 // sendErrorMessage sends an error message to the client
 func (h *WebSocketHandler) sendErrorMessage(ws *wsutil.WebSocketConnection, id, path, errorMsg string) {
+	h.sendContextErrorMessage(ws, id, path, "", errorMsg)
+}
+
+// sendContextErrorMessage sends an error message about one context of a multi-context subscription
+func (h *WebSocketHandler) sendContextErrorMessage(ws *wsutil.WebSocketConnection, id, path, contextName, errorMsg string) {
 	msg := ServerMessage{
-		ID:    id,
-		Type:  "error",
-		Path:  path,
-		Error: errorMsg,
+		ID:      id,
+		Type:    "error",
+		Path:    path,
+		Error:   errorMsg,
+		Context: contextName,
 	}
 	h.sendMessage(ws, &msg)
 }
@@ -423,7 +608,7 @@ func stripManagedFields(raw json.RawMessage) json.RawMessage {
 	return json.RawMessage(b)
 }
 
-// handleHelmReleaseWatch handles watching Helm releases with polling
+// handleHelmReleaseWatch handles watching Helm releases through the objects their storage driver keeps them in
 func (h *WebSocketHandler) handleHelmReleaseWatch(ctx context.Context, ws *wsutil.WebSocketConnection, msg *ClientMessage) {
 	// Extract namespace from the path if present
 	// Path format: "/api/helm/releases" (all namespaces) or "/api/helm/releases/namespaces/{namespace}"
@@ -435,32 +620,82 @@ func (h *WebSocketHandler) handleHelmReleaseWatch(ctx context.Context, ws *wsuti
 		namespace = pathParts[5]
 	}
 
-	// Store previous releases to detect changes
-	var previousReleases []*helm.Release
+	// Releases are stored in Secrets or ConfigMaps, one per revision. Watching them (through the shared watch,
+	// so every subscriber of the namespace uses the same upstream watch) tells which release changed,
+	// and only that one is decoded. Releases in SQL can't be watched, they are polled.
+	storagePath, watchable := helm.ReleaseStoragePath(h.helmClient.Driver(), namespace)
+	if !watchable {
+		h.pollHelmReleases(ctx, ws, msg, namespace)
+		return
+	}
+	sub := h.watchMux.Subscribe(h.k8sClient, storagePath)
+	tracker := helm.NewReleaseTracker(h.helmClient.Driver())
 
-	// Start polling in a goroutine
 	go func() {
-		ticker := time.NewTicker(2 * time.Second)
-		defer ticker.Stop()
+		defer sub.Close()
 
-		// Get initial state
-		startTime := time.Now()
-		releases, err := h.helmClient.ListReleases(ctx, namespace)
-		if err != nil {
-			log.Printf("Error listing Helm releases: %v", err)
-			h.sendErrorMessage(ws, msg.ID, msg.Path, fmt.Sprintf("Failed to list Helm releases: %v", err))
-			return
-		}
+		for {
+			select {
+			case <-ctx.Done():
+				if h.accessLogEnabled {
+					log.Printf("Context cancelled for Helm releases watch: %s", msg.Path)
+				}
+				return
+			case event, ok := <-sub.Events():
+				if !ok {
+					err := sub.Err()
+					log.Printf("Error watching Helm release storage: %v", err)
+					h.sendErrorMessage(ws, msg.ID, msg.Path, fmt.Sprintf("Failed to watch Helm releases: %v", err))
+					return
+				}
 
-		// Send initial data
-		h.compareAndSendHelmChanges(ws, msg, []*helm.Release{}, releases)
-		previousReleases = releases
-		elapsed := time.Since(startTime)
-		if h.accessLogEnabled {
-			log.Printf("Helm releases loading time: %v for namespace: %s", elapsed, namespace)
+				// The initial list and bursts of revisions are decoded once per release
+				batch := collectWatchBatch(ctx, sub.Events(), event)
+				for _, change := range tracker.Apply(batch) {
+					h.sendDataMessage(ws, msg.ID, msg.Path, &kubernetes.WatchEvent{
+						Type:   change.Type,
+						Object: h.helmReleaseToRawMessage(change.Release),
+					})
+				}
+			}
 		}
-		// Poll for changes
+	}()
+}
+
+// pollHelmReleases lists the releases every few seconds and sends the ones that changed,
+// for storage drivers that can't be watched
+func (h *WebSocketHandler) pollHelmReleases(ctx context.Context, ws *wsutil.WebSocketConnection, msg *ClientMessage, namespace string) {
+	go func() {
+		ticker := time.NewTicker(2 * time.Second)
+		defer ticker.Stop()
+
+		previous := map[string]*helm.Release{}
 		for {
+			releases, err := h.helmClient.ListReleases(ctx, namespace)
+			if err != nil {
+				log.Printf("Error listing Helm releases: %v", err)
+				h.sendErrorMessage(ws, msg.ID, msg.Path, fmt.Sprintf("Failed to list Helm releases: %v", err))
+			} else {
+				current := make(map[string]*helm.Release, len(releases))
+				for _, rel := range releases {
+					key := rel.Namespace + "/" + rel.Name
+					current[key] = rel
+					prev, exists := previous[key]
+					switch {
+					case !exists:
+						h.sendDataMessage(ws, msg.ID, msg.Path, &kubernetes.WatchEvent{Type: "ADDED", Object: h.helmReleaseToRawMessage(rel)})
+					case prev.Revision != rel.Revision || prev.Status != rel.Status || !prev.Updated.Equal(rel.Updated):
+						h.sendDataMessage(ws, msg.ID, msg.Path, &kubernetes.WatchEvent{Type: "MODIFIED", Object: h.helmReleaseToRawMessage(rel)})
+					}
+				}
+				for key, prev := range previous {
+					if _, exists := current[key]; !exists {
+						h.sendDataMessage(ws, msg.ID, msg.Path, &kubernetes.WatchEvent{Type: "DELETED", Object: h.helmReleaseToRawMessage(prev)})
+					}
+				}
+				previous = current
+			}
+
 			select {
 			case <-ctx.Done():
 				if h.accessLogEnabled {
@@ -468,20 +703,53 @@ func (h *WebSocketHandler) handleHelmReleaseWatch(ctx context.Context, ws *wsuti
 				}
 				return
 			case <-ticker.C:
-				pollStartTime := time.Now()
-				currentReleases, err := h.helmClient.ListReleases(ctx, namespace)
-				if err != nil {
-					log.Printf("Error listing Helm releases: %v", err)
-					h.sendErrorMessage(ws, msg.ID, msg.Path, fmt.Sprintf("Failed to list Helm releases: %v", err))
-					continue
-				}
+			}
+		}
+	}()
+}
 
-				h.compareAndSendHelmChanges(ws, msg, previousReleases, currentReleases)
-				previousReleases = currentReleases
-				pollElapsed := time.Since(pollStartTime)
-				if h.accessLogEnabled {
-					log.Printf("Helm releases poll time: %v for namespace: %s", pollElapsed, namespace)
+// helmJobProgressEventType is the type of the watch events that carry the progress of a Helm job
+const helmJobProgressEventType = "PROGRESS"
+
+// handleHelmJobWatch streams the progress of a background Helm job, like a rollback.
+// Path format: "/api/helm/jobs/{id}". Events from before the subscription are replayed first,
+// the last event of a job is a status event with the finished job.
+func (h *WebSocketHandler) handleHelmJobWatch(ctx context.Context, ws *wsutil.WebSocketConnection, msg *ClientMessage) {
+	pathParts := strings.Split(strings.TrimSuffix(msg.Path, "/"), "/")
+	id := pathParts[len(pathParts)-1]
+	if h.helmJobs == nil {
+		h.sendErrorMessage(ws, msg.ID, msg.Path, "Helm jobs are not available on this connection")
+		return
+	}
+	past, events, cancel, ok := h.helmJobs.Subscribe(id)
+	if !ok {
+		h.sendErrorMessage(ws, msg.ID, msg.Path, fmt.Sprintf("Helm job %s not found", id))
+		return
+	}
+
+	send := func(ev helm.JobEvent) {
+		data, err := json.Marshal(ev)
+		if err != nil {
+			log.Printf("Error marshaling Helm job event: %v", err)
+			return
+		}
+		h.sendDataMessage(ws, msg.ID, msg.Path, &kubernetes.WatchEvent{Type: helmJobProgressEventType, Object: data})
+	}
+
+	go func() {
+		defer cancel()
+		for _, ev := range past {
+			send(ev)
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case ev, ok := <-events:
+				if !ok {
+					return
 				}
+				send(ev)
 			}
 		}
 	}()
@@ -606,56 +874,8 @@ func (h *WebSocketHandler) sendHelmHistory(ws *wsutil.WebSocketConnection, id, p
 	h.sendMessage(ws, msg)
 }
 
-// compareAndSendHelmChanges compares release lists and sends appropriate events
-func (h *WebSocketHandler) compareAndSendHelmChanges(ws *wsutil.WebSocketConnection, msg *ClientMessage, previous, current []*helm.Release) {
-	// Create maps for easier comparison
-	prevMap := make(map[string]*helm.Release)
-	for _, rel := range previous {
-		key := fmt.Sprintf("%s/%s", rel.Namespace, rel.Name)
-		prevMap[key] = rel
-	}
-
-	currMap := make(map[string]*helm.Release)
-	for _, rel := range current {
-		key := fmt.Sprintf("%s/%s", rel.Namespace, rel.Name)
-		currMap[key] = rel
-	}
-
-	// Check for new or modified releases
-	for key, currRel := range currMap {
-		if prevRel, exists := prevMap[key]; exists {
-			// Check if modified (compare revision and status)
-			if prevRel.Revision != currRel.Revision || prevRel.Status != currRel.Status || !prevRel.Updated.Equal(currRel.Updated) {
-				event := &kubernetes.WatchEvent{
-					Type:   "MODIFIED",
-					Object: h.helmReleaseToRawMessage(currRel),
-				}
-				h.sendDataMessage(ws, msg.ID, msg.Path, event)
-			}
-		} else {
-			// New release
-			event := &kubernetes.WatchEvent{
-				Type:   "ADDED",
-				Object: h.helmReleaseToRawMessage(currRel),
-			}
-			h.sendDataMessage(ws, msg.ID, msg.Path, event)
-		}
-	}
-
-	// Check for deleted releases
-	for key, prevRel := range prevMap {
-		if _, exists := currMap[key]; !exists {
-			event := &kubernetes.WatchEvent{
-				Type:   "DELETED",
-				Object: h.helmReleaseToRawMessage(prevRel),
-			}
-			h.sendDataMessage(ws, msg.ID, msg.Path, event)
-		}
-	}
-}
-
 // handleKluctlDeploymentWatch handles watching Kluctl pseudo Deployments backed by result secrets.
-// It polls the underlying Secrets via the Kubernetes client on a fixed interval and sends watch-style events.
+// It watches the result Secrets and sends an event only when a pseudo Deployment actually changed.
 func (h *WebSocketHandler) handleKluctlDeploymentWatch(ctx context.Context, ws *wsutil.WebSocketConnection, msg *ClientMessage) {
 	// Determine namespace filter from the path, if present.
 	// Path formats:
@@ -679,67 +899,54 @@ func (h *WebSocketHandler) handleKluctlDeploymentWatch(ctx context.Context, ws *
 		commandResultNamespace = "kluctl-results"
 	}
 
-	// Poll in a goroutine similar to Helm releases.
+	sub := h.watchMux.Subscribe(h.k8sClient, kluctlResultSecretsPath(commandResultNamespace))
+	tracker := newKluctlDeploymentTracker(commandResultNamespace, namespaceFilter)
+
 	go func() {
-		ticker := time.NewTicker(5 * time.Second)
-		defer ticker.Stop()
+		defer sub.Close()
 
-		// Helper to send a full snapshot of all Kluctl deployments once.
-		sendSnapshot := func() {
-			summaries, payloads, err := ListCommandResultSummariesWithPayload(ctx, h.k8sClient, commandResultNamespace)
-			if err != nil {
-				log.Printf("Error listing Kluctl command results: %v", err)
-				h.sendErrorMessage(ws, msg.ID, msg.Path, fmt.Sprintf("Failed to list Kluctl command results: %v", err))
+		for {
+			select {
+			case <-ctx.Done():
+				log.Printf("Context cancelled for Kluctl deployments watch: %s", msg.Path)
 				return
-			}
-
-			groups := GroupCommandResultSummaries(summaries)
-			for _, g := range groups {
-				obj := BuildKluctlDeploymentObject(g, payloads)
-				// Ensure a namespace is always present on the pseudo Deployment; fall back to
-				// the command result namespace when KluctlDeploymentInfo.Namespace is absent.
-				if obj.Metadata.Namespace == "" {
-					obj.Metadata.Namespace = commandResultNamespace
-				}
-				if namespaceFilter != "" && obj.Metadata.Namespace != namespaceFilter {
-					continue
-				}
-
-				wireObj := map[string]interface{}{
-					"apiVersion": obj.APIVersion,
-					"kind":       obj.Kind,
-					"metadata":   obj.Metadata,
-					"spec":       obj.Spec,
-					"status":     obj.Status,
-				}
-
-				data, err := json.Marshal(wireObj)
-				if err != nil {
-					log.Printf("Error marshaling Kluctl deployment object: %v", err)
-					continue
+			case event, ok := <-sub.Events():
+				if !ok {
+					err := sub.Err()
+					log.Printf("Error watching Kluctl command results: %v", err)
+					h.sendErrorMessage(ws, msg.ID, msg.Path, fmt.Sprintf("Failed to watch Kluctl command results: %v", err))
+					return
 				}
 
-				event := &kubernetes.WatchEvent{
-					Type:   "MODIFIED",
-					Object: json.RawMessage(data),
+				// The initial list and bursts of results rebuild each deployment once
+				batch := collectWatchBatch(ctx, sub.Events(), event)
+				for _, change := range tracker.Apply(batch) {
+					h.sendDataMessage(ws, msg.ID, msg.Path, change)
 				}
-				h.sendDataMessage(ws, msg.ID, msg.Path, event)
 			}
 		}
+	}()
+}
 
-		// Send an initial snapshot immediately so clients don't wait for the first tick.
-		sendSnapshot()
+// collectWatchBatch returns the first event together with the events that arrive within watchBatchWindow after it
+func collectWatchBatch(ctx context.Context, events <-chan *kubernetes.WatchEvent, first *kubernetes.WatchEvent) []*kubernetes.WatchEvent {
+	batch := []*kubernetes.WatchEvent{first}
+	window := time.NewTimer(watchBatchWindow)
+	defer window.Stop()
 
-		for {
-			select {
-			case <-ctx.Done():
-				log.Printf("Context cancelled for Kluctl deployments watch: %s", msg.Path)
-				return
-			case <-ticker.C:
-				sendSnapshot()
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return batch
 			}
+			batch = append(batch, event)
+		case <-window.C:
+			return batch
+		case <-ctx.Done():
+			return batch
 		}
-	}()
+	}
 }
 
 // helmReleaseToRawMessage converts a Helm release to json.RawMessage
@@ -0,0 +1,225 @@
+// Copyright 2025 Laszlo Consulting Kft.
+// SPDX-License-Identifier: Apache-2.0
+
+package wsutil
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gimlet-io/capacitor/pkg/kubernetes"
+)
+
+// CoalescingQueue sits between a watch and a WebSocket client that may be slower than the watch.
+// It holds at most one pending event per object (keyed by UID), so while the client catches up
+// only the latest state of each object is kept. MODIFIED events of an object are also limited
+// to a maximum rate; the ones in between are merged into the next.
+// Merged and dropped events are reported in the counters.
+type CoalescingQueue struct {
+	mu          sync.Mutex
+	minInterval time.Duration
+	counters    *Counters
+
+	order    []string // pending keys, oldest first
+	pending  map[string]*kubernetes.WatchEvent
+	lastSent map[string]time.Time
+	seq      int
+
+	notify chan struct{}
+}
+
+// NewCoalescingQueue creates a queue that delivers at most maxUpdateRate MODIFIED events per second
+// for each object. Zero or a negative rate means no limit.
+func NewCoalescingQueue(maxUpdateRate float64, counters *Counters) *CoalescingQueue {
+	var minInterval time.Duration
+	if maxUpdateRate > 0 {
+		minInterval = time.Duration(float64(time.Second) / maxUpdateRate)
+	}
+	return &CoalescingQueue{
+		minInterval: minInterval,
+		counters:    counters,
+		pending:     make(map[string]*kubernetes.WatchEvent),
+		lastSent:    make(map[string]time.Time),
+		notify:      make(chan struct{}, 1),
+	}
+}
+
+// ParseMaxUpdateRate reads params["maxUpdateRate"], falling back to the given default
+func ParseMaxUpdateRate(params map[string]string, def float64) float64 {
+	if raw, ok := params["maxUpdateRate"]; ok {
+		if rate, err := strconv.ParseFloat(raw, 64); err == nil {
+			return rate
+		}
+	}
+	return def
+}
+
+// Push adds an event to the queue, merging it with the pending event of the same object if there is one
+func (q *CoalescingQueue) Push(ev *kubernetes.WatchEvent) {
+	if ev == nil {
+		return
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	defer q.wake()
+
+	key, ok := coalesceKey(ev)
+	if !ok {
+		// Events that are not about a single object (SYNCED, ERROR) are never merged
+		q.seq++
+		key = "\x00" + strconv.Itoa(q.seq)
+		q.order = append(q.order, key)
+		q.pending[key] = ev
+		return
+	}
+
+	prev, exists := q.pending[key]
+	if !exists {
+		q.order = append(q.order, key)
+		q.pending[key] = ev
+		return
+	}
+
+	merged := *ev
+	dropped := false
+	switch {
+	case prev.Type == "DELETED" && ev.Type == "ADDED":
+		// Deleted and re-created while the client was behind: an update as far as the client is concerned
+		merged.Type = "MODIFIED"
+	case ev.Type == "DELETED":
+		// Also after an ADDED: after a re-list or resume the watch sends ADDED for objects the client
+		// already has, so it has to hear about the deletion. Deletes of unknown objects are ignored.
+		merged.Type = "DELETED"
+		dropped = true
+	default:
+		// ADDED stays ADDED until the client has seen it, MODIFIED stays MODIFIED
+		merged.Type = prev.Type
+	}
+	q.pending[key] = &merged
+	switch {
+	case q.counters == nil:
+	case dropped:
+		// The state the client hasn't seen yet is never sent
+		q.counters.AddDropped(1)
+	default:
+		q.counters.AddMerged(1)
+	}
+}
+
+// Pop returns the oldest event that may be sent now, waiting for one if needed.
+// It returns false when the context is done.
+func (q *CoalescingQueue) Pop(ctx context.Context) (*kubernetes.WatchEvent, bool) {
+	for {
+		ev, wait := q.next(time.Now())
+		if ev != nil {
+			return ev, true
+		}
+
+		var (
+			timer   *time.Timer
+			timerCh <-chan time.Time
+		)
+		if wait > 0 {
+			timer = time.NewTimer(wait)
+			timerCh = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-q.notify:
+		case <-timerCh:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return nil, false
+		}
+	}
+}
+
+// Len returns the number of pending events
+func (q *CoalescingQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.order)
+}
+
+// next takes the oldest event that isn't held back by the rate limit.
+// Events of other objects may overtake a held MODIFIED, markers like SYNCED never do,
+// as the client takes them to mean that everything before them arrived.
+// If there is none, it returns how long until one becomes ready (zero if the queue is empty).
+func (q *CoalescingQueue) next(now time.Time) (*kubernetes.WatchEvent, time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var wait time.Duration
+	for i, key := range q.order {
+		ev := q.pending[key]
+		if isMarkerKey(key) && wait > 0 {
+			break
+		}
+		if ev.Type == "MODIFIED" && q.minInterval > 0 {
+			if readyAt := q.lastSent[key].Add(q.minInterval); readyAt.After(now) {
+				if d := readyAt.Sub(now); wait == 0 || d < wait {
+					wait = d
+				}
+				continue
+			}
+		}
+
+		q.order = append(q.order[:i], q.order[i+1:]...)
+		delete(q.pending, key)
+		switch {
+		case isMarkerKey(key):
+		case ev.Type == "DELETED":
+			delete(q.lastSent, key)
+		default:
+			q.lastSent[key] = now
+		}
+		return ev, 0
+	}
+	return nil, wait
+}
+
+func (q *CoalescingQueue) wake() {
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+// coalesceKey identifies the object of an event by UID, or by namespace and name
+func coalesceKey(ev *kubernetes.WatchEvent) (string, bool) {
+	switch ev.Type {
+	case "ADDED", "MODIFIED", "DELETED":
+	default:
+		return "", false
+	}
+	var obj struct {
+		Metadata struct {
+			UID       string `json:"uid"`
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal(ev.Object, &obj); err != nil {
+		return "", false
+	}
+	if obj.Metadata.UID != "" {
+		return obj.Metadata.UID, true
+	}
+	if obj.Metadata.Name == "" {
+		return "", false
+	}
+	return obj.Metadata.Namespace + "/" + obj.Metadata.Name, true
+}
+
+// isMarkerKey reports whether a queue key belongs to an event that is not about a single object
+func isMarkerKey(key string) bool {
+	return len(key) > 0 && key[0] == 0
+}
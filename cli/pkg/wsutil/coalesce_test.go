@@ -0,0 +1,153 @@
+// Copyright 2025 Laszlo Consulting Kft.
+// SPDX-License-Identifier: Apache-2.0
+
+package wsutil
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gimlet-io/capacitor/pkg/kubernetes"
+)
+
+func watchEvent(typ, uid, version string) *kubernetes.WatchEvent {
+	obj, _ := json.Marshal(map[string]interface{}{
+		"metadata": map[string]string{"uid": uid, "name": uid, "resourceVersion": version},
+	})
+	return &kubernetes.WatchEvent{Type: typ, Object: obj}
+}
+
+func eventVersion(ev *kubernetes.WatchEvent) string {
+	var obj struct {
+		Metadata struct {
+			ResourceVersion string `json:"resourceVersion"`
+		} `json:"metadata"`
+	}
+	_ = json.Unmarshal(ev.Object, &obj)
+	return obj.Metadata.ResourceVersion
+}
+
+// drain pops every event that is ready at now, as "TYPE uid@version", or "TYPE" for markers
+func drain(q *CoalescingQueue, now time.Time) []string {
+	var out []string
+	for {
+		ev, _ := q.next(now)
+		if ev == nil {
+			return out
+		}
+		key, ok := coalesceKey(ev)
+		if !ok {
+			out = append(out, ev.Type)
+			continue
+		}
+		out = append(out, ev.Type+" "+key+"@"+eventVersion(ev))
+	}
+}
+
+func TestCoalescingQueueMerge(t *testing.T) {
+	tests := []struct {
+		name        string
+		events      []*kubernetes.WatchEvent
+		want        []string
+		wantMerged  int64
+		wantDropped int64
+	}{
+		{
+			name:   "different objects keep their order",
+			events: []*kubernetes.WatchEvent{watchEvent("ADDED", "a", "1"), watchEvent("ADDED", "b", "2")},
+			want:   []string{"ADDED a@1", "ADDED b@2"},
+		},
+		{
+			name:       "modifications keep the latest state",
+			events:     []*kubernetes.WatchEvent{watchEvent("MODIFIED", "a", "1"), watchEvent("MODIFIED", "a", "2"), watchEvent("MODIFIED", "a", "3")},
+			want:       []string{"MODIFIED a@3"},
+			wantMerged: 2,
+		},
+		{
+			name:       "added stays added",
+			events:     []*kubernetes.WatchEvent{watchEvent("ADDED", "a", "1"), watchEvent("MODIFIED", "a", "2")},
+			want:       []string{"ADDED a@2"},
+			wantMerged: 1,
+		},
+		{
+			// After a re-list the client may already have the object, so the deletion must get through
+			name:        "added then deleted is a delete",
+			events:      []*kubernetes.WatchEvent{watchEvent("ADDED", "a", "1"), watchEvent("DELETED", "a", "2")},
+			want:        []string{"DELETED a@2"},
+			wantDropped: 1,
+		},
+		{
+			name:        "modified then deleted is a delete",
+			events:      []*kubernetes.WatchEvent{watchEvent("MODIFIED", "a", "1"), watchEvent("DELETED", "a", "2")},
+			want:        []string{"DELETED a@2"},
+			wantDropped: 1,
+		},
+		{
+			name:       "deleted then re-created is a modification",
+			events:     []*kubernetes.WatchEvent{watchEvent("DELETED", "a", "1"), watchEvent("ADDED", "a", "2")},
+			want:       []string{"MODIFIED a@2"},
+			wantMerged: 1,
+		},
+		{
+			name:   "markers are never merged",
+			events: []*kubernetes.WatchEvent{{Type: "SYNCED"}, watchEvent("ADDED", "a", "1"), {Type: "SYNCED"}},
+			want:   []string{"SYNCED", "ADDED a@1", "SYNCED"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counters := &Counters{}
+			q := NewCoalescingQueue(0, counters)
+			for _, ev := range tt.events {
+				q.Push(ev)
+			}
+
+			got := drain(q, time.Now())
+			if len(got) != len(tt.want) {
+				t.Fatalf("got events %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got events %v, want %v", got, tt.want)
+				}
+			}
+
+			merged, dropped := counters.CoalesceSnapshot()
+			if merged != tt.wantMerged || dropped != tt.wantDropped {
+				t.Errorf("merged = %d, dropped = %d, want %d and %d", merged, dropped, tt.wantMerged, tt.wantDropped)
+			}
+		})
+	}
+}
+
+func TestCoalescingQueueRateLimit(t *testing.T) {
+	q := NewCoalescingQueue(1, nil)
+	now := time.Now()
+
+	q.Push(watchEvent("MODIFIED", "a", "1"))
+	if got := drain(q, now); len(got) != 1 || got[0] != "MODIFIED a@1" {
+		t.Fatalf("first update should go out right away, got %v", got)
+	}
+
+	q.Push(watchEvent("MODIFIED", "a", "2"))
+	q.Push(watchEvent("MODIFIED", "b", "3"))
+	q.Push(&kubernetes.WatchEvent{Type: "SYNCED"})
+
+	// b may overtake the held update of a, the marker may not
+	if got := drain(q, now.Add(100*time.Millisecond)); len(got) != 1 || got[0] != "MODIFIED b@3" {
+		t.Fatalf("got %v, want only the update of b", got)
+	}
+	if _, wait := q.next(now.Add(100 * time.Millisecond)); wait != 900*time.Millisecond {
+		t.Errorf("wait = %v, want 900ms", wait)
+	}
+
+	got := drain(q, now.Add(time.Second))
+	if len(got) != 2 || got[0] != "MODIFIED a@2" || got[1] != "SYNCED" {
+		t.Fatalf("got %v, want the held update of a, then the marker", got)
+	}
+	if q.Len() != 0 {
+		t.Errorf("Len() = %d, want an empty queue", q.Len())
+	}
+}
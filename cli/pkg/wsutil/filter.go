@@ -0,0 +1,317 @@
+// Copyright 2025 Laszlo Consulting Kft.
+// SPDX-License-Identifier: Apache-2.0
+
+package wsutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gimlet-io/capacitor/pkg/kubernetes"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+// Filter narrows a watch down to the objects matching every condition of a subscription:
+//
+//	params.labelSelector  Kubernetes label selector, e.g. app=web,tier!=cache
+//	params.fieldSelector  Kubernetes field selector on any dot path, e.g. metadata.namespace=default
+//	params.name           case-insensitive substring of metadata.name
+//	params.nameRegex      regular expression on metadata.name
+//	params.where          predicates as a JSON array or comma-separated list, e.g. status.phase!=Running.
+//	                      A predicate is path=value, path==value, path!=value, path (set and not empty) or !path.
+//	                      If the path crosses an array, any element may match (for != none may match).
+//
+// A filter remembers which objects it let through, so an object that stops matching is sent as DELETED.
+type Filter struct {
+	labels     labels.Selector
+	predicates []predicate
+	name       string
+	nameRegex  *regexp.Regexp
+	key        string
+
+	visible map[string]string // objects the client has, keyed like the coalescing queue, to namespace/name
+}
+
+type predicate struct {
+	path     []string
+	operator selection.Operator // Equals, NotEquals, Exists or DoesNotExist
+	value    string
+}
+
+// filterParams are the subscription params ParseFilter reads
+var filterParams = []string{"labelSelector", "fieldSelector", "name", "nameRegex", "where"}
+
+// ParseFilter builds the filter of a subscription. It returns nil if the params don't ask for filtering.
+func ParseFilter(params map[string]string) (*Filter, error) {
+	f := &Filter{visible: make(map[string]string)}
+	keyValues := url.Values{}
+	for _, param := range filterParams {
+		if v := strings.TrimSpace(params[param]); v != "" {
+			keyValues.Set(param, v)
+		}
+	}
+	if len(keyValues) == 0 {
+		return nil, nil
+	}
+
+	if raw := keyValues.Get("labelSelector"); raw != "" {
+		selector, err := labels.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid labelSelector: %w", err)
+		}
+		f.labels = selector
+	}
+
+	if raw := keyValues.Get("fieldSelector"); raw != "" {
+		selector, err := fields.ParseSelector(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fieldSelector: %w", err)
+		}
+		for _, req := range selector.Requirements() {
+			op := req.Operator
+			if op == selection.DoubleEquals {
+				op = selection.Equals
+			}
+			f.predicates = append(f.predicates, predicate{path: strings.Split(req.Field, "."), operator: op, value: req.Value})
+		}
+	}
+
+	f.name = strings.ToLower(keyValues.Get("name"))
+
+	if raw := keyValues.Get("nameRegex"); raw != "" {
+		re, err := regexp.Compile(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid nameRegex: %w", err)
+		}
+		f.nameRegex = re
+	}
+
+	where := parseListParam(params, "where")
+	for _, raw := range where {
+		p, err := parsePredicate(raw)
+		if err != nil {
+			return nil, err
+		}
+		f.predicates = append(f.predicates, p)
+	}
+	if len(where) > 0 {
+		sorted := append([]string(nil), where...)
+		sort.Strings(sorted)
+		keyValues.Set("where", strings.Join(sorted, ","))
+	}
+
+	f.key = keyValues.Encode()
+	return f, nil
+}
+
+// parsePredicate parses a single params.where predicate
+func parsePredicate(raw string) (predicate, error) {
+	var p predicate
+	var path string
+	switch {
+	case strings.Contains(raw, "!="):
+		parts := strings.SplitN(raw, "!=", 2)
+		path, p.operator, p.value = parts[0], selection.NotEquals, parts[1]
+	case strings.Contains(raw, "=="):
+		parts := strings.SplitN(raw, "==", 2)
+		path, p.operator, p.value = parts[0], selection.Equals, parts[1]
+	case strings.Contains(raw, "="):
+		parts := strings.SplitN(raw, "=", 2)
+		path, p.operator, p.value = parts[0], selection.Equals, parts[1]
+	case strings.HasPrefix(raw, "!"):
+		path, p.operator = raw[1:], selection.DoesNotExist
+	default:
+		path, p.operator = raw, selection.Exists
+	}
+
+	path = strings.TrimSpace(path)
+	p.value = strings.TrimSpace(p.value)
+	if path == "" {
+		return p, fmt.Errorf("invalid where predicate %q: missing path", raw)
+	}
+	p.path = strings.Split(path, ".")
+	for _, segment := range p.path {
+		if segment == "" {
+			return p, fmt.Errorf("invalid where predicate %q: empty path segment", raw)
+		}
+	}
+	return p, nil
+}
+
+// Key identifies the filter in watch keys; filters with the same conditions have the same key
+func (f *Filter) Key() string {
+	if f == nil {
+		return ""
+	}
+	return f.key
+}
+
+// Apply filters a watch event. It returns nil for events the client must not get,
+// and a DELETED event for an object that no longer matches.
+func (f *Filter) Apply(ev *kubernetes.WatchEvent) *kubernetes.WatchEvent {
+	if ev.Type == kubernetes.SyncedEventType {
+		f.prune(ev.Object)
+		return ev
+	}
+
+	key, ok := coalesceKey(ev)
+	if !ok {
+		return ev
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal(ev.Object, &obj); err != nil {
+		return ev
+	}
+	_, wasVisible := f.visible[key]
+
+	switch {
+	case ev.Type == "DELETED":
+		if !wasVisible {
+			return nil
+		}
+		delete(f.visible, key)
+		return ev
+	case f.Matches(obj):
+		f.visible[key] = kubernetes.ObjectNamespacedName(ev.Object)
+		if !wasVisible && ev.Type == "MODIFIED" {
+			// The object just started to match, the client hasn't seen it yet
+			return &kubernetes.WatchEvent{Type: "ADDED", Object: ev.Object}
+		}
+		return ev
+	case wasVisible:
+		delete(f.visible, key)
+		return &kubernetes.WatchEvent{Type: "DELETED", Object: ev.Object}
+	default:
+		return nil
+	}
+}
+
+// Matches reports whether a decoded object satisfies every condition of the filter
+func (f *Filter) Matches(obj map[string]interface{}) bool {
+	md, _ := obj["metadata"].(map[string]interface{})
+	name, _ := md["name"].(string)
+
+	if f.name != "" && !strings.Contains(strings.ToLower(name), f.name) {
+		return false
+	}
+	if f.nameRegex != nil && !f.nameRegex.MatchString(name) {
+		return false
+	}
+	if f.labels != nil {
+		set := labels.Set{}
+		if objLabels, ok := md["labels"].(map[string]interface{}); ok {
+			for k, v := range objLabels {
+				if s, ok := v.(string); ok {
+					set[k] = s
+				}
+			}
+		}
+		if !f.labels.Matches(set) {
+			return false
+		}
+	}
+	for _, p := range f.predicates {
+		if !p.matches(obj) {
+			return false
+		}
+	}
+	return true
+}
+
+// prune forgets the visible objects missing from the re-list described by a SYNCED event
+func (f *Filter) prune(raw json.RawMessage) {
+	var synced kubernetes.WatchSync
+	if err := json.Unmarshal(raw, &synced); err != nil {
+		return
+	}
+	listed := make(map[string]struct{}, len(synced.Objects))
+	for _, name := range synced.Objects {
+		listed[name] = struct{}{}
+	}
+	for key, name := range f.visible {
+		if _, ok := listed[name]; !ok {
+			delete(f.visible, key)
+		}
+	}
+}
+
+func (p predicate) matches(obj map[string]interface{}) bool {
+	values := lookupPath(obj, p.path)
+	switch p.operator {
+	case selection.Exists:
+		for _, v := range values {
+			if s, ok := scalarString(v); !ok || s != "" {
+				return true
+			}
+		}
+		return false
+	case selection.DoesNotExist:
+		for _, v := range values {
+			if s, ok := scalarString(v); !ok || s != "" {
+				return false
+			}
+		}
+		return true
+	case selection.NotEquals:
+		for _, v := range values {
+			if s, ok := scalarString(v); ok && s == p.value {
+				return false
+			}
+		}
+		return true
+	default:
+		for _, v := range values {
+			if s, ok := scalarString(v); ok && s == p.value {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// lookupPath returns every value at a dot path, fanning out over arrays on the way
+func lookupPath(cur interface{}, path []string) []interface{} {
+	if arr, ok := cur.([]interface{}); ok {
+		var out []interface{}
+		for _, el := range arr {
+			out = append(out, lookupPath(el, path)...)
+		}
+		return out
+	}
+	if len(path) == 0 {
+		if cur == nil {
+			return nil
+		}
+		return []interface{}{cur}
+	}
+	m, ok := cur.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	child, ok := m[path[0]]
+	if !ok {
+		return nil
+	}
+	return lookupPath(child, path[1:])
+}
+
+// scalarString formats a decoded JSON scalar for comparison; objects and arrays are not scalars
+func scalarString(v interface{}) (string, bool) {
+	switch t := v.(type) {
+	case string:
+		return t, true
+	case bool:
+		return strconv.FormatBool(t), true
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64), true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2025 Laszlo Consulting Kft.
+// SPDX-License-Identifier: Apache-2.0
+
+package wsutil
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gimlet-io/capacitor/pkg/kubernetes"
+)
+
+const filterTestPod = `{
+	"metadata": {"uid": "1", "name": "web-7d4f", "namespace": "default", "labels": {"app": "web", "tier": "frontend"}},
+	"spec": {"nodeName": "", "containers": [{"name": "app", "image": "nginx"}, {"name": "proxy", "image": "envoy"}]},
+	"status": {"phase": "Running", "ready": true, "restarts": 3}
+}`
+
+func TestFilterMatches(t *testing.T) {
+	tests := []struct {
+		name   string
+		params map[string]string
+		want   bool
+	}{
+		{name: "label", params: map[string]string{"labelSelector": "app=web"}, want: true},
+		{name: "label set", params: map[string]string{"labelSelector": "tier in (frontend,backend),app"}, want: true},
+		{name: "label mismatch", params: map[string]string{"labelSelector": "app!=web"}},
+		{name: "field", params: map[string]string{"fieldSelector": "metadata.namespace=default"}, want: true},
+		{name: "field mismatch", params: map[string]string{"fieldSelector": "status.phase==Pending"}},
+		{name: "name substring", params: map[string]string{"name": "WEB"}, want: true},
+		{name: "name mismatch", params: map[string]string{"name": "db"}},
+		{name: "name regex", params: map[string]string{"nameRegex": "^web-[0-9a-f]+$"}, want: true},
+		{name: "name regex mismatch", params: map[string]string{"nameRegex": "^db-"}},
+		{name: "where", params: map[string]string{"where": "status.phase!=Pending"}, want: true},
+		{name: "where mismatch", params: map[string]string{"where": "status.phase!=Running"}},
+		{name: "where bool", params: map[string]string{"where": "status.ready=true"}, want: true},
+		{name: "where number", params: map[string]string{"where": "status.restarts==3"}, want: true},
+		{name: "where list", params: map[string]string{"where": `["status.phase=Running","metadata.labels.app=web"]`}, want: true},
+		{name: "where list mismatch", params: map[string]string{"where": "status.phase=Running,metadata.labels.app=db"}},
+		{name: "exists", params: map[string]string{"where": "metadata.labels.tier"}, want: true},
+		{name: "empty is not set", params: map[string]string{"where": "spec.nodeName"}},
+		{name: "does not exist", params: map[string]string{"where": "!metadata.deletionTimestamp"}, want: true},
+		{name: "does not exist mismatch", params: map[string]string{"where": "!status"}},
+		{name: "any array element", params: map[string]string{"where": "spec.containers.image=envoy"}, want: true},
+		{name: "no array element", params: map[string]string{"where": "spec.containers.image!=envoy"}},
+		{name: "all conditions", params: map[string]string{"labelSelector": "app=web", "name": "db"}},
+	}
+
+	var obj map[string]interface{}
+	if err := json.Unmarshal([]byte(filterTestPod), &obj); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := ParseFilter(tt.params)
+			if err != nil {
+				t.Fatalf("ParseFilter() error = %v", err)
+			}
+			if got := f.Matches(obj); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	f, err := ParseFilter(map[string]string{"fields": "metadata.name", "name": " "})
+	if err != nil || f != nil {
+		t.Fatalf("ParseFilter() = %v, %v, want no filter", f, err)
+	}
+
+	for _, params := range []map[string]string{
+		{"labelSelector": "app in (web"},
+		{"fieldSelector": "status.phase>1"},
+		{"nameRegex": "web-("},
+		{"where": "=Running"},
+		{"where": "status..phase=Running"},
+	} {
+		if _, err := ParseFilter(params); err == nil {
+			t.Errorf("ParseFilter(%v) accepted invalid params", params)
+		}
+	}
+
+	a, _ := ParseFilter(map[string]string{"where": "status.phase=Running,metadata.labels.app=web"})
+	b, _ := ParseFilter(map[string]string{"where": `["metadata.labels.app=web","status.phase=Running"]`})
+	if a.Key() != b.Key() {
+		t.Errorf("Key() = %q and %q, want the same key for the same predicates", a.Key(), b.Key())
+	}
+	c, _ := ParseFilter(map[string]string{"where": "status.phase=Running"})
+	if a.Key() == c.Key() {
+		t.Errorf("Key() = %q for different filters", a.Key())
+	}
+}
+
+func TestFilterApply(t *testing.T) {
+	pod := func(typ, phase string) *kubernetes.WatchEvent {
+		obj, _ := json.Marshal(map[string]interface{}{
+			"metadata": map[string]string{"uid": "1", "name": "web", "namespace": "default"},
+			"status":   map[string]string{"phase": phase},
+		})
+		return &kubernetes.WatchEvent{Type: typ, Object: obj}
+	}
+	synced := func(objects ...string) *kubernetes.WatchEvent {
+		obj, _ := json.Marshal(kubernetes.WatchSync{Objects: objects})
+		return &kubernetes.WatchEvent{Type: kubernetes.SyncedEventType, Object: obj}
+	}
+
+	f, err := ParseFilter(map[string]string{"where": "status.phase=Running"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps := []struct {
+		event *kubernetes.WatchEvent
+		want  string // type of the event the client gets, empty for none
+	}{
+		{pod("ADDED", "Pending"), ""},
+		{pod("MODIFIED", "Pending"), ""},
+		{pod("MODIFIED", "Running"), "ADDED"},
+		{pod("MODIFIED", "Running"), "MODIFIED"},
+		{pod("MODIFIED", "Failed"), "DELETED"},
+		{pod("DELETED", "Failed"), ""},
+		{pod("ADDED", "Running"), "ADDED"},
+		{synced(), kubernetes.SyncedEventType},
+		// The re-list dropped the object, the client no longer has it
+		{pod("MODIFIED", "Running"), "ADDED"},
+		{pod("DELETED", "Running"), "DELETED"},
+	}
+
+	for i, step := range steps {
+		got := ""
+		if ev := f.Apply(step.event); ev != nil {
+			got = ev.Type
+		}
+		if got != step.want {
+			t.Fatalf("step %d: Apply(%s) = %q, want %q", i, step.event.Type, got, step.want)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright 2025 Laszlo Consulting Kft.
+// SPDX-License-Identifier: Apache-2.0
+
+package wsutil
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"github.com/gimlet-io/capacitor/pkg/kubernetes"
+)
+
+// PatchEncoding is the value of params["encoding"] that turns on merge-patch deltas
+const PatchEncoding = "patch"
+
+// DefaultPatchResyncInterval is how often an object is sent in full even if a patch would do,
+// so a client that applied a patch wrong doesn't stay wrong for long
+const DefaultPatchResyncInterval = 2 * time.Minute
+
+// PatchEncoder turns MODIFIED events into RFC 7386 JSON merge patches against the
+// last version of the object it sent. ADDED and DELETED events, and MODIFIED events of objects
+// the client hasn't seen or that are due for a resync, are sent in full.
+// The encoder keeps one copy of every object it sent, so it is only used when a subscription asks for it.
+type PatchEncoder struct {
+	resyncInterval time.Duration
+	last           map[string]sentObject
+}
+
+type sentObject struct {
+	name   string // namespace/name, to prune on SYNCED
+	object map[string]interface{}
+	synced time.Time
+}
+
+// NewPatchEncoder creates an encoder that sends every object in full at least once per resyncInterval
+func NewPatchEncoder(resyncInterval time.Duration) *PatchEncoder {
+	return &PatchEncoder{
+		resyncInterval: resyncInterval,
+		last:           make(map[string]sentObject),
+	}
+}
+
+// ParsePatchEncoding reports whether the subscription asked for merge-patch deltas
+func ParsePatchEncoding(params map[string]string) bool {
+	return params["encoding"] == PatchEncoding
+}
+
+// Encode returns the event to send and whether its object is a merge patch.
+// The event must already be transformed (see TransformWatchEvent), the patch is computed
+// against what the client received. Encode is not safe for concurrent use.
+func (e *PatchEncoder) Encode(ev *kubernetes.WatchEvent, now time.Time) (*kubernetes.WatchEvent, bool) {
+	if ev == nil {
+		return ev, false
+	}
+	if ev.Type == kubernetes.SyncedEventType {
+		e.prune(ev.Object)
+		return ev, false
+	}
+
+	key, ok := coalesceKey(ev)
+	if !ok {
+		return ev, false
+	}
+	if ev.Type == "DELETED" {
+		delete(e.last, key)
+		return ev, false
+	}
+
+	var obj map[string]interface{}
+	if err := json.Unmarshal(ev.Object, &obj); err != nil {
+		delete(e.last, key)
+		return ev, false
+	}
+
+	prev, seen := e.last[key]
+	if ev.Type != "MODIFIED" || !seen || now.Sub(prev.synced) >= e.resyncInterval {
+		e.last[key] = sentObject{name: kubernetes.ObjectNamespacedName(ev.Object), object: obj, synced: now}
+		return ev, false
+	}
+
+	patch, ok := mergePatch(prev.object, obj)
+	if !ok {
+		// The change can't be expressed as a merge patch, e.g. a field set to null
+		e.last[key] = sentObject{name: prev.name, object: obj, synced: now}
+		return ev, false
+	}
+	addPatchIdentity(patch, obj)
+	raw, err := json.Marshal(patch)
+	if err != nil || len(raw) >= len(ev.Object) {
+		e.last[key] = sentObject{name: prev.name, object: obj, synced: now}
+		return ev, false
+	}
+
+	e.last[key] = sentObject{name: prev.name, object: obj, synced: prev.synced}
+	return &kubernetes.WatchEvent{Type: ev.Type, Object: json.RawMessage(raw)}, true
+}
+
+// prune forgets the objects that are missing from the re-list described by a SYNCED event
+func (e *PatchEncoder) prune(raw json.RawMessage) {
+	var synced kubernetes.WatchSync
+	if err := json.Unmarshal(raw, &synced); err != nil {
+		return
+	}
+	listed := make(map[string]struct{}, len(synced.Objects))
+	for _, name := range synced.Objects {
+		listed[name] = struct{}{}
+	}
+	for key, sent := range e.last {
+		if _, ok := listed[sent.name]; !ok {
+			delete(e.last, key)
+		}
+	}
+}
+
+// addPatchIdentity copies the fields the client finds the object by into the patch.
+// They are unchanged, so applying them is a no-op.
+func addPatchIdentity(patch, obj map[string]interface{}) {
+	for _, field := range []string{"apiVersion", "kind"} {
+		if v, ok := obj[field]; ok {
+			patch[field] = v
+		}
+	}
+	md, ok := obj["metadata"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	patchMd, ok := patch["metadata"].(map[string]interface{})
+	if !ok {
+		patchMd = map[string]interface{}{}
+		patch["metadata"] = patchMd
+	}
+	for _, field := range []string{"uid", "name", "namespace"} {
+		if v, ok := md[field]; ok {
+			patchMd[field] = v
+		}
+	}
+}
+
+// mergePatch computes the RFC 7386 merge patch that turns original into modified.
+// Arrays are replaced as a whole. It returns false if modified has a null value the
+// patch would have to carry, as a null in a merge patch means removal.
+func mergePatch(original, modified map[string]interface{}) (map[string]interface{}, bool) {
+	patch := map[string]interface{}{}
+	for key := range original {
+		if _, ok := modified[key]; !ok {
+			patch[key] = nil
+		}
+	}
+	for key, mv := range modified {
+		ov, existed := original[key]
+		if existed && reflect.DeepEqual(ov, mv) {
+			continue
+		}
+		if mv == nil {
+			return nil, false
+		}
+		om, oIsMap := ov.(map[string]interface{})
+		mm, mIsMap := mv.(map[string]interface{})
+		if existed && oIsMap && mIsMap {
+			sub, ok := mergePatch(om, mm)
+			if !ok {
+				return nil, false
+			}
+			patch[key] = sub
+			continue
+		}
+		if mIsMap && containsNull(mm) {
+			return nil, false
+		}
+		patch[key] = mv
+	}
+	return patch, true
+}
+
+// containsNull reports whether a value has a null anywhere in its objects
+func containsNull(v interface{}) bool {
+	switch t := v.(type) {
+	case nil:
+		return true
+	case map[string]interface{}:
+		for _, child := range t {
+			if containsNull(child) {
+				return true
+			}
+		}
+	}
+	return false
+}
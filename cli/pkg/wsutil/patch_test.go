@@ -0,0 +1,168 @@
+// Copyright 2025 Laszlo Consulting Kft.
+// SPDX-License-Identifier: Apache-2.0
+
+package wsutil
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gimlet-io/capacitor/pkg/kubernetes"
+)
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		original string
+		modified string
+		want     string // empty if no merge patch can express the change
+	}{
+		{
+			name:     "unchanged",
+			original: `{"a":1,"b":{"c":2}}`,
+			modified: `{"a":1,"b":{"c":2}}`,
+			want:     `{}`,
+		},
+		{
+			name:     "changed field",
+			original: `{"a":1,"b":2}`,
+			modified: `{"a":1,"b":3}`,
+			want:     `{"b":3}`,
+		},
+		{
+			name:     "nested field",
+			original: `{"status":{"phase":"Pending","ready":false}}`,
+			modified: `{"status":{"phase":"Running","ready":false}}`,
+			want:     `{"status":{"phase":"Running"}}`,
+		},
+		{
+			name:     "removed field",
+			original: `{"a":1,"b":{"c":2,"d":3}}`,
+			modified: `{"a":1,"b":{"c":2}}`,
+			want:     `{"b":{"d":null}}`,
+		},
+		{
+			name:     "added object",
+			original: `{"a":1}`,
+			modified: `{"a":1,"b":{"c":2}}`,
+			want:     `{"b":{"c":2}}`,
+		},
+		{
+			name:     "arrays are replaced",
+			original: `{"list":[1,2,3]}`,
+			modified: `{"list":[1,2]}`,
+			want:     `{"list":[1,2]}`,
+		},
+		{
+			name:     "map replaced by a scalar",
+			original: `{"a":{"b":1}}`,
+			modified: `{"a":"b"}`,
+			want:     `{"a":"b"}`,
+		},
+		{
+			name:     "field set to null",
+			original: `{"a":1}`,
+			modified: `{"a":null}`,
+		},
+		{
+			name:     "added object with a null",
+			original: `{}`,
+			modified: `{"a":{"b":null}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var original, modified map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.original), &original); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal([]byte(tt.modified), &modified); err != nil {
+				t.Fatal(err)
+			}
+
+			patch, ok := mergePatch(original, modified)
+			if tt.want == "" {
+				if ok {
+					t.Fatalf("mergePatch() = %v, want no patch", patch)
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("mergePatch() found no patch, want %s", tt.want)
+			}
+			var want map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(patch, want) {
+				t.Errorf("mergePatch() = %v, want %v", patch, want)
+			}
+		})
+	}
+}
+
+func podEvent(typ, name, phase, padding string) *kubernetes.WatchEvent {
+	obj, _ := json.Marshal(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   map[string]string{"uid": name, "name": name, "namespace": "default"},
+		"spec":       map[string]string{"padding": padding},
+		"status":     map[string]string{"phase": phase},
+	})
+	return &kubernetes.WatchEvent{Type: typ, Object: obj}
+}
+
+func TestPatchEncoder(t *testing.T) {
+	// Large enough for a patch of the phase to be smaller than the object
+	padding := string(make([]byte, 200))
+	now := time.Now()
+	e := NewPatchEncoder(time.Minute)
+
+	if _, isPatch := e.Encode(podEvent("ADDED", "web", "Pending", padding), now); isPatch {
+		t.Fatalf("an ADDED event must be sent in full")
+	}
+
+	ev, isPatch := e.Encode(podEvent("MODIFIED", "web", "Running", padding), now)
+	if !isPatch {
+		t.Fatalf("a MODIFIED event of a sent object should be a patch")
+	}
+	var patch map[string]interface{}
+	if err := json.Unmarshal(ev.Object, &patch); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   map[string]interface{}{"uid": "web", "name": "web", "namespace": "default"},
+		"status":     map[string]interface{}{"phase": "Running"},
+	}
+	if !reflect.DeepEqual(patch, want) {
+		t.Errorf("patch = %v, want %v", patch, want)
+	}
+
+	if _, isPatch := e.Encode(podEvent("MODIFIED", "web", "Failed", padding), now.Add(time.Minute)); isPatch {
+		t.Errorf("an object due for a resync must be sent in full")
+	}
+
+	if _, isPatch := e.Encode(podEvent("MODIFIED", "db", "Running", padding), now); isPatch {
+		t.Errorf("an object the client hasn't seen must be sent in full")
+	}
+
+	// A re-list without web forgets it, so its next update goes out in full
+	synced, _ := json.Marshal(kubernetes.WatchSync{Objects: []string{"default/db"}})
+	e.Encode(&kubernetes.WatchEvent{Type: kubernetes.SyncedEventType, Object: synced}, now)
+	if _, isPatch := e.Encode(podEvent("MODIFIED", "web", "Running", padding), now); isPatch {
+		t.Errorf("an object missing from the re-list must be sent in full")
+	}
+	if _, isPatch := e.Encode(podEvent("MODIFIED", "db", "Failed", padding), now); !isPatch {
+		t.Errorf("an object listed in the re-list should still be patched")
+	}
+
+	e.Encode(podEvent("DELETED", "db", "Failed", padding), now)
+	if _, isPatch := e.Encode(podEvent("MODIFIED", "db", "Running", padding), now); isPatch {
+		t.Errorf("a deleted object must be sent in full")
+	}
+}
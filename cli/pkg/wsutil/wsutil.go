@@ -50,21 +50,33 @@ type Counters struct {
 	objects      int64
 	managedBytes int64
 	bytesSent    int64
+	merged       int64 // events folded into a newer event of the same object by a CoalescingQueue
+	dropped      int64 // events never sent as the object was deleted before the client caught up
 }
 
 func (c *Counters) AddObjects(n int64)      { atomic.AddInt64(&c.objects, n) }
 func (c *Counters) AddManagedBytes(n int64) { atomic.AddInt64(&c.managedBytes, n) }
 func (c *Counters) AddBytesSent(n int64)    { atomic.AddInt64(&c.bytesSent, n) }
+func (c *Counters) AddMerged(n int64)       { atomic.AddInt64(&c.merged, n) }
+func (c *Counters) AddDropped(n int64)      { atomic.AddInt64(&c.dropped, n) }
 func (c *Counters) Snapshot() (objects, bytes int64) {
 	return atomic.LoadInt64(&c.objects), atomic.LoadInt64(&c.bytesSent)
 }
+func (c *Counters) CoalesceSnapshot() (merged, dropped int64) {
+	return atomic.LoadInt64(&c.merged), atomic.LoadInt64(&c.dropped)
+}
 
 // ParseProjectionFields extracts fields from params["fields"] supporting JSON array or comma-separated list
 func ParseProjectionFields(params map[string]string) []string {
+	return parseListParam(params, "fields")
+}
+
+// parseListParam reads params[key] as a JSON array or a comma-separated list
+func parseListParam(params map[string]string, key string) []string {
 	if params == nil {
 		return nil
 	}
-	raw, ok := params["fields"]
+	raw, ok := params[key]
 	if !ok || strings.TrimSpace(raw) == "" {
 		return nil
 	}
@@ -90,13 +102,24 @@ func ParseProjectionFields(params map[string]string) []string {
 	return out
 }
 
-// TransformWatchEvent strips managedFields and applies projection, returning a shallow-copied event
-// and the number of removed bytes from managedFields (best-effort)
-func TransformWatchEvent(ev *kubernetes.WatchEvent, fields []string) (*kubernetes.WatchEvent, int) {
+// TransformWatchEvent applies the filter, strips managedFields and applies projection, returning a shallow-copied event
+// and the number of removed bytes from managedFields (best-effort).
+// It returns nil if the filter drops the event; the filter may also turn it into a DELETED event.
+func TransformWatchEvent(ev *kubernetes.WatchEvent, fields []string, filter *Filter) (*kubernetes.WatchEvent, int) {
 	if ev == nil {
 		return nil, 0
 	}
+	// The filter sees the whole object, predicates may be about fields the projection leaves out
+	if filter != nil {
+		if ev = filter.Apply(ev); ev == nil {
+			return nil, 0
+		}
+	}
 	out := *ev // shallow copy
+	// SYNCED carries the list of live objects, not an object; projecting it would drop the list
+	if out.Type == kubernetes.SyncedEventType {
+		return &out, 0
+	}
 	removed := 0
 	if len(out.Object) > 0 {
 		stripped, rm := StripManagedFieldsCounted(out.Object)
@@ -288,13 +311,16 @@ func ProjectObjectByFields(raw json.RawMessage, fields []string) json.RawMessage
 
 // StatsEvent builds a kubernetes.WatchEvent with Type STATS and payload of counters snapshot
 func StatsEvent(c *Counters) *kubernetes.WatchEvent {
-	var objs, sent int64
+	var objs, sent, merged, dropped int64
 	if c != nil {
 		objs, sent = c.Snapshot()
+		merged, dropped = c.CoalesceSnapshot()
 	}
 	payload := map[string]interface{}{
 		"objects":         objs,
 		"bytesSent":       sent,
+		"merged":          merged,
+		"dropped":         dropped,
 		"intervalSeconds": 1,
 	}
 	data, _ := json.Marshal(payload)
@@ -87,7 +87,7 @@ function DashboardPane(props: DashboardPaneProps) {
   const [loadingStage, setLoadingStage] = createSignal<'loading' | 'enhancing' | 'filtering' | null>(null);
   const [settleTimer, setSettleTimer] = createSignal<number | null>(null);
   const [watchControllers, setWatchControllers] = createSignal<AbortController[]>([]);
-  const mainBatchQueue: Record<string, Array<{ type: 'ADDED' | 'MODIFIED' | 'DELETED'; object: any }>> = {};
+  const mainBatchQueue: Record<string, Array<{ type: 'ADDED' | 'MODIFIED' | 'DELETED' | 'SYNCED'; object: any }>> = {};
   const mainBatchTimer: Record<string, number | undefined> = {};
   const extraBatchQueue: Record<string, Array<{ type: 'ADDED' | 'MODIFIED' | 'DELETED'; object: any }>> = {};
   const extraBatchTimer: Record<string, number | undefined> = {};
@@ -322,6 +322,22 @@ function DashboardPane(props: DashboardPaneProps) {
             }
             for (const evt of changes) {
               const obj = evt.object;
+              // After the backend re-listed an expired watch, drop the objects deleted in the meantime
+              if (evt.type === 'SYNCED') {
+                const listed = new Set<string>(Array.isArray(obj?.objects) ? obj.objects : []);
+                const kept = list.filter((item: any) => {
+                  const ns = item?.metadata?.namespace;
+                  const n = item?.metadata?.name;
+                  return listed.has(ns ? `${ns}/${n}` : n);
+                });
+                list.splice(0, list.length, ...kept);
+                nameToIndex.clear();
+                for (let i = 0; i < list.length; i++) {
+                  const n = (list[i] as any)?.metadata?.name as string | undefined;
+                  if (n) nameToIndex.set(n, i);
+                }
+                continue;
+              }
               const name = obj?.metadata?.name as string | undefined;
               if (!name) continue;
               const idx = nameToIndex.get(name);
@@ -410,8 +426,14 @@ function DashboardPane(props: DashboardPaneProps) {
             return;
           }
           const obj = event.object;
-          if (!obj?.metadata?.name) return;
           const rt = k8sResource.id;
+          if (event.type === 'SYNCED') {
+            if (!Array.isArray(mainBatchQueue[rt])) mainBatchQueue[rt] = [];
+            mainBatchQueue[rt].push({ type: 'SYNCED', object: obj });
+            scheduleMainFlush(rt);
+            return;
+          }
+          if (!obj?.metadata?.name) return;
           if (!Array.isArray(mainBatchQueue[rt])) mainBatchQueue[rt] = [];
           mainBatchQueue[rt].push({ type: event.type as any, object: obj });
           if (event.type === 'ADDED') bumpSettleTimer();
@@ -22,6 +22,10 @@ export function HelmHistory(props: {
   const [loading, setLoading] = createSignal<boolean>(true);
   const [selectedRevisionIndex, setSelectedRevisionIndex] = createSignal<number>(-1);
   const [canRollback, setCanRollback] = createSignal<boolean | undefined>(undefined);
+  const [rollbackProgress, setRollbackProgress] = createSignal<string>("");
+  const [testRunning, setTestRunning] = createSignal<boolean>(false);
+  const [testHooks, setTestHooks] = createSignal<{ name: string; phase: string }[]>([]);
+  const [testLogs, setTestLogs] = createSignal<{ [hook: string]: string[] }>({});
 
   const [expandedDiffs, setExpandedDiffs] = createSignal<{ [key: string]: { expanded: boolean; diffType: "values" | "manifest" } }>({});
   const [diffData, setDiffData] = createSignal<{ [key: string]: any }>({});
@@ -31,6 +35,8 @@ export function HelmHistory(props: {
   const checkPermission = useCheckPermissionSSAR();
   let tableRef: HTMLTableElement | undefined;
   let unsubscribeHistory: (() => void) | null = null;
+  let unsubscribeRollback: (() => void) | null = null;
+  let unsubscribeTests: (() => void) | null = null;
 
   // Permission check
   createEffect(() => {
@@ -72,6 +78,8 @@ export function HelmHistory(props: {
   });
   onCleanup(() => {
     if (unsubscribeHistory) unsubscribeHistory();
+    if (unsubscribeRollback) unsubscribeRollback();
+    if (unsubscribeTests) unsubscribeTests();
   });
 
   // Emit selected revision upwards
@@ -96,12 +104,110 @@ export function HelmHistory(props: {
       const url = (ctxName ? `/api/${ctxName}` : '/api') + `/helm/rollback/${props.namespace}/${props.name}/${revisionNumber}`;
       const response = await fetch(url, { method: "POST" });
       if (!response.ok) throw new Error(`Failed to rollback release: ${response.statusText}`);
+      const job = await response.json();
+      watchRollbackJob(ctxName, job.id, revisionNumber);
     } catch (error) {
       console.error("Error rolling back release:", error);
       alert(`Failed to rollback: ${error instanceof Error ? error.message : String(error)}`);
     }
   };
 
+  // The rollback runs as a job on the server, follow its progress until it finishes
+  const watchRollbackJob = (ctxName: string, jobId: string, revisionNumber: number) => {
+    if (unsubscribeRollback) {
+      unsubscribeRollback();
+      unsubscribeRollback = null;
+    }
+    setRollbackProgress(`Rolling back to revision ${revisionNumber}...`);
+    const wsClient = getWebSocketClient(ctxName);
+    wsClient.watchResource(`/api/helm/jobs/${jobId}`, (data) => {
+      const ev = data?.object;
+      if (!ev) return;
+      if (ev.type === "release") {
+        setRollbackProgress(`Release is ${ev.releaseStatus} (revision ${ev.revision})`);
+      } else if (ev.type === "resource" && ev.resource) {
+        const r = ev.resource;
+        setRollbackProgress(`${r.kind} ${r.name}: ${r.status}${r.message ? ` - ${r.message}` : ""}`);
+      } else if (ev.type === "status" && ev.job?.finishedAt) {
+        if (ev.job.status === "failed") {
+          setRollbackProgress("");
+          alert(`Failed to rollback: ${ev.job.error}`);
+        } else {
+          setRollbackProgress(`Rolled back to revision ${revisionNumber}`);
+        }
+        if (unsubscribeRollback) {
+          unsubscribeRollback();
+          unsubscribeRollback = null;
+        }
+      }
+    }).then((unsubscribe) => {
+      unsubscribeRollback = unsubscribe;
+    }).catch((error) => {
+      console.error("Error watching rollback:", error);
+    });
+  };
+
+  // Tests: run the chart's test hooks as a job and follow their pods' phases and logs
+  const runTests = async () => {
+    if (testRunning() || canRollback() === false) return;
+    try {
+      const ctxName = apiResourceStore.contextInfo?.current ? encodeURIComponent(apiResourceStore.contextInfo.current) : '';
+      const url = (ctxName ? `/api/${ctxName}` : '/api') + `/helm/tests/${props.namespace}/${props.name}`;
+      const response = await fetch(url, { method: "POST" });
+      if (!response.ok) throw new Error(`Failed to run tests: ${response.statusText}`);
+      const job = await response.json();
+      watchTestJob(ctxName, job.id);
+    } catch (error) {
+      console.error("Error running tests:", error);
+      alert(`Failed to run tests: ${error instanceof Error ? error.message : String(error)}`);
+    }
+  };
+
+  const watchTestJob = (ctxName: string, jobId: string) => {
+    if (unsubscribeTests) {
+      unsubscribeTests();
+      unsubscribeTests = null;
+    }
+    setTestRunning(true);
+    setTestHooks([]);
+    setTestLogs({});
+    const wsClient = getWebSocketClient(ctxName);
+    wsClient.watchResource(`/api/helm/jobs/${jobId}`, (data) => {
+      const ev = data?.object;
+      if (!ev) return;
+      if (ev.type === "hook" && ev.hook) {
+        const hook = { name: ev.hook.name, phase: ev.hook.phase };
+        setTestHooks((prev) => prev.some((h) => h.name === hook.name) ? prev.map((h) => h.name === hook.name ? hook : h) : [...prev, hook]);
+      } else if (ev.type === "log" && ev.hook) {
+        setTestLogs((prev) => ({ ...prev, [ev.hook.name]: [...(prev[ev.hook.name] || []), ev.message || ""] }));
+      } else if (ev.type === "status" && ev.job?.finishedAt) {
+        setTestRunning(false);
+        if (ev.job.status === "failed" && testHooks().length === 0) {
+          alert(`Failed to run tests: ${ev.job.error}`);
+        }
+        if (unsubscribeTests) {
+          unsubscribeTests();
+          unsubscribeTests = null;
+        }
+      }
+    }).then((unsubscribe) => {
+      unsubscribeTests = unsubscribe;
+    }).catch((error) => {
+      setTestRunning(false);
+      console.error("Error watching tests:", error);
+    });
+  };
+
+  const getTestPhaseColor = (phase: string) => {
+    switch (phase) {
+      case "Succeeded": return "var(--success-color)";
+      case "Failed": return "var(--error-color)";
+      case "Running":
+      case "Pending": return "var(--warning-color)";
+      default: return "var(--linear-text-secondary)";
+    }
+  };
+
   // Diff helpers (copied from HelmDrawer)
   const fetchReleaseValuesDiff = async (fromRevision: number, toRevision: number) => {
     const diffKey = `${toRevision}-${fromRevision}`;
@@ -429,7 +535,7 @@ export function HelmHistory(props: {
     );
   };
 
-  // Keyboard: navigate rows and rollback
+  // Keyboard: navigate rows, rollback and run tests
   const handleKeyDown = (e: KeyboardEvent): boolean | void => {
     const currentIndex = selectedRevisionIndex();
     let newIndex = currentIndex;
@@ -465,6 +571,12 @@ export function HelmHistory(props: {
       handled = true;
     }
 
+    if (doesEventMatchShortcut(e, "mod+t")) {
+      e.preventDefault();
+      runTests();
+      handled = true;
+    }
+
     if (!handled) {
       return false;
     }
@@ -512,13 +624,38 @@ export function HelmHistory(props: {
     <Show when={!loading()} fallback={<div class="drawer-loading">Loading...</div>}>
       <Show when={historyData().length > 0} fallback={<div class="no-history">No release history found</div>}>
         <div class="keyboard-shortcut-container" style="display: flex; justify-content: flex-end; margin-bottom: 8px;">
+          <Show when={rollbackProgress()}>
+            <span class="shortcut-description" style="margin-right: auto;">{rollbackProgress()}</span>
+          </Show>
           <div class="keyboard-shortcut">
             <span class={`shortcut-key ${canRollback() === false ? 'disabled' : ''}`}>
               {formatShortcutForDisplay('mod+r')}
             </span>
             <span class={`shortcut-description ${canRollback() === false ? 'disabled' : ''}`}>Rollback to selected revision</span>
           </div>
+          <div class="keyboard-shortcut">
+            <span class={`shortcut-key ${canRollback() === false || testRunning() ? 'disabled' : ''}`}>
+              {formatShortcutForDisplay('mod+t')}
+            </span>
+            <span class={`shortcut-description ${canRollback() === false || testRunning() ? 'disabled' : ''}`}>{testRunning() ? "Running tests..." : "Run tests"}</span>
+          </div>
         </div>
+        <Show when={testHooks().length > 0}>
+          <div class="helm-test-results" style="margin-bottom: 8px;">
+            <For each={testHooks()}>
+              {(hook) => (
+                <div style="margin-bottom: 4px;">
+                  <div>
+                    <span style={{ color: getTestPhaseColor(hook.phase) }}>{hook.phase}</span> {hook.name}
+                  </div>
+                  <Show when={(testLogs()[hook.name] || []).length > 0}>
+                    <pre style="max-height: 200px; overflow: auto; margin: 4px 0;">{testLogs()[hook.name].join("\n")}</pre>
+                  </Show>
+                </div>
+              )}
+            </For>
+          </div>
+        </Show>
         <table class="helm-history-table" ref={tableRef}>
           <thead>
             <tr>
@@ -394,7 +394,7 @@ export function ResourceList<T>(props: {
 }) {
   const paneFilterStore = usePaneFilterStore();
   const apiStore = useApiResourceStore();
-  const { permissionElevation } = useAppConfig();
+  const { permissionElevation, readOnly } = useAppConfig();
 
   const [selectedIndex, setSelectedIndex] = createSignal(-1);
   const [selectedKey, setSelectedKey] = createSignal<string | null>(null);
@@ -526,6 +526,23 @@ export function ResourceList<T>(props: {
     if (desc === 'describe' || desc === 'yaml' || desc === 'events' || desc === 'manifest' || desc === 'values' || desc === 'release history') {
       return undefined;
     }
+    // Logs
+    if (key === 'l' && desc.includes('logs')) {
+      const allowed = await checkPermission(resource, { verb: 'get', subresource: 'log', resourceOverride: 'pods', groupOverride: '', nameOverride: resource.kind === 'Pod' ? resource.metadata.name : null });
+      return allowed;
+    }
+    // The server refuses every remaining command that changes the cluster in read-only mode
+    if (readOnly() && (
+      (desc.includes('delete') && key.includes('+d')) ||
+      (key === 'x' && (desc.includes('exec') || desc.includes('debug node'))) ||
+      (desc.includes('scale') && key.includes('+s')) ||
+      (desc.includes('run cronjob') && key.includes('+t')) ||
+      (desc.includes('rollout restart') && key.includes('+r')) ||
+      desc.startsWith('reconcile') ||
+      (desc.includes('edit') && key.includes('+e'))
+    )) {
+      return false;
+    }
     // Delete (pod deletion is elevated with workloadRestart for specific namespaces)
     if (desc.includes('delete') && (key.includes('+d'))) {
       const allowed = await checkPermission(resource, { verb: 'delete', nameOverride: resource.metadata.name });
@@ -535,11 +552,6 @@ export function ResourceList<T>(props: {
       }
       return allowed;
     }
-    // Logs
-    if (key === 'l' && desc.includes('logs')) {
-      const allowed = await checkPermission(resource, { verb: 'get', subresource: 'log', resourceOverride: 'pods', groupOverride: '', nameOverride: resource.kind === 'Pod' ? resource.metadata.name : null });
-      return allowed;
-    }
     // Exec
     if (key === 'x' && desc.includes('exec')) {
       const allowed = await checkPermission(resource, { verb: 'create', subresource: 'exec', resourceOverride: 'pods', groupOverride: '', nameOverride: resource.kind === 'Pod' ? resource.metadata.name : null });
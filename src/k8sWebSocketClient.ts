@@ -11,6 +11,8 @@ export class K8sWebSocketClient {
   private callbacksById: Map<string, Set<(event: any) => void>> = new Map();
   // Map composite key (path + canonical params) to subscription metadata
   private subscriptionByKey: Map<string, { id: string; path: string; params?: Record<string, string> }> = new Map();
+  // Last full object per object key, for subscriptions with params.encoding=patch (null for the others)
+  private patchBaseById: Map<string, Map<string, any> | null> = new Map();
   private connectionPromise: Promise<void> | null = null;
   private reconnectAttempts: number = 0;
   private maxReconnectAttempts: number = 10;
@@ -115,7 +117,7 @@ export class K8sWebSocketClient {
                 }
                 const bytes = Number((stats as any).bytesSent) || 0;
                 const kb = Math.round(bytes / 1024);
-                console.log('[WS stats] objects=%s sent=%s KB interval=%ss', (stats as any).objects ?? 0, kb, (stats as any).intervalSeconds ?? 3);
+                console.log('[WS stats] objects=%s sent=%s KB merged=%s dropped=%s interval=%ss', (stats as any).objects ?? 0, kb, (stats as any).merged ?? 0, (stats as any).dropped ?? 0, (stats as any).intervalSeconds ?? 3);
               } catch (_e) {
                 // noop
               }
@@ -140,9 +142,11 @@ export class K8sWebSocketClient {
           if (message.type === 'data') {
             const callbacks = this.callbacksById.get(message.id);
             if (!callbacks) return;
+            const data = this.decodeData(message);
+            if (!data) return;
             for (const cb of callbacks) {
               try {
-                cb(message.data);
+                cb(data);
               } catch (e) {
                 console.error('Subscriber callback error:', e);
               }
@@ -172,6 +176,46 @@ export class K8sWebSocketClient {
     return this.connectionPromise;
   }
   
+  /**
+   * Returns the event of a data message with merge patches applied, so subscribers always get full objects.
+   * Returns null for a patch of an object this client hasn't seen, the next resync fixes it.
+   */
+  private decodeData(message: any): any {
+    const data = message.data;
+    const base = this.patchBaseFor(message.id);
+    if (!base || !data || !data.object) return data;
+
+    const md = data.object.metadata || {};
+    const key = md.uid || `${md.namespace || ''}/${md.name || ''}`;
+    if (data.type === 'DELETED') {
+      base.delete(key);
+      return data;
+    }
+    if (message.encoding !== 'patch') {
+      if (data.type === 'ADDED' || data.type === 'MODIFIED') base.set(key, data.object);
+      return data;
+    }
+
+    const prev = base.get(key);
+    if (!prev) {
+      console.warn(`[WS] Dropping patch for unknown object ${key} on id=${message.id}`);
+      return null;
+    }
+    const object = applyMergePatch(prev, data.object);
+    base.set(key, object);
+    return { ...data, object };
+  }
+
+  private patchBaseFor(id: string): Map<string, any> | null {
+    if (this.patchBaseById.has(id)) return this.patchBaseById.get(id) || null;
+    let base: Map<string, any> | null = null;
+    for (const sub of this.subscriptionByKey.values()) {
+      if (sub.id === id && sub.params?.encoding === 'patch') base = new Map();
+    }
+    this.patchBaseById.set(id, base);
+    return base;
+  }
+
   /**
    * Watches a Kubernetes resource
    * @param path The path to watch (e.g. "/api/v1/namespaces/default/pods")
@@ -195,6 +239,7 @@ export class K8sWebSocketClient {
       }
       // Remove old mapping
       this.callbacksById.delete(oldId);
+      this.patchBaseById.delete(oldId);
       // Create new subscription id and replace mapping
       const newId = Math.random().toString(36).substring(2, 15);
       this.subscriptionByKey.set(subKey, { id: newId, path, params });
@@ -216,6 +261,7 @@ export class K8sWebSocketClient {
         console.log(`[WS] Removed callback from id=${currentId}. remaining=${set.size}`);
         if (set.size === 0) {
           this.callbacksById.delete(currentId);
+          this.patchBaseById.delete(currentId);
           this.subscriptionByKey.delete(subKey);
           if (this.connected && this.ws) {
             console.log(`[WS] No callbacks remain, unsubscribing id=${currentId} path=${(current as any).path}`);
@@ -246,6 +292,7 @@ export class K8sWebSocketClient {
         cur.delete(callback);
         if (cur.size === 0) {
           this.callbacksById.delete(id);
+          this.patchBaseById.delete(id);
         }
       }
       this.subscriptionByKey.delete(subKey);
@@ -277,6 +324,7 @@ export class K8sWebSocketClient {
       console.log(`[WS] Removed callback from id=${currentId}. remaining=${set.size}`);
       if (set.size === 0) {
         this.callbacksById.delete(currentId);
+        this.patchBaseById.delete(currentId);
         this.subscriptionByKey.delete(subKey);
         if (this.connected && this.ws) {
           console.log(`[WS] No callbacks remain, unsubscribing id=${currentId} path=${(current as any).path}`);
@@ -409,3 +457,18 @@ export function getWebSocketClient(contextName?: string): K8sWebSocketClient {
   }
   return existing;
 }
+
+// applyMergePatch applies an RFC 7386 JSON merge patch without modifying target
+export function applyMergePatch(target: any, patch: any): any {
+  if (patch === null || typeof patch !== 'object' || Array.isArray(patch)) return patch;
+  const result: Record<string, any> =
+    target !== null && typeof target === 'object' && !Array.isArray(target) ? { ...target } : {};
+  for (const [key, value] of Object.entries(patch)) {
+    if (value === null) {
+      delete result[key];
+    } else {
+      result[key] = applyMergePatch(result[key], value);
+    }
+  }
+  return result;
+}
@@ -10,6 +10,7 @@ export type AppConfigPayload = {
   systemViews?: unknown;
   fluxcd?: unknown;
   permissionElevation?: unknown;
+  readOnly?: boolean;
   // Allow additional keys without typing every field here.
   // deno-lint-ignore no-explicit-any
   [key: string]: any;
@@ -85,6 +86,8 @@ type AppConfigContextValue = {
   fluxcdConfig: Accessor<FluxcdConfig | null>;
   carvelConfig: Accessor<CarvelConfig | null>;
   permissionElevation: Accessor<PermissionElevationConfig | null>;
+  // True when the server refuses every mutating operation (started with --read-only)
+  readOnly: Accessor<boolean>;
 };
 
 const AppConfigContext = createContext<AppConfigContextValue>();
@@ -96,6 +99,7 @@ export function AppConfigProvider(props: { children: JSX.Element }) {
   const [fluxcdConfig, setFluxcdConfig] = createSignal<FluxcdConfig | null>(null);
   const [carvelConfig, setCarvelConfig] = createSignal<CarvelConfig | null>(null);
   const [permissionElevation, setPermissionElevation] = createSignal<PermissionElevationConfig | null>(null);
+  const [readOnly, setReadOnly] = createSignal<boolean>(false);
 
   onMount(() => {
     (async () => {
@@ -109,6 +113,7 @@ export function AppConfigProvider(props: { children: JSX.Element }) {
         }
         const data = (await res.json()) as AppConfigPayload;
         setAppConfig(data);
+        setReadOnly(data?.readOnly === true);
 
         // Parse fluxcd config
         const rawFluxcd = (data && (data as any).fluxcd) as any;
@@ -160,6 +165,7 @@ export function AppConfigProvider(props: { children: JSX.Element }) {
         fluxcdConfig,
         carvelConfig,
         permissionElevation,
+        readOnly,
       }}
     >
       {props.children}
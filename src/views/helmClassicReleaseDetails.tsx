@@ -15,7 +15,6 @@ import { HelmValues } from "../components/resourceDetail/HelmValues.tsx";
 import { HelmManifest } from "../components/resourceDetail/HelmManifest.tsx";
 import { HelmManifestDiff } from "../components/resourceDetail/HelmManifestDiff.tsx";
 import { HelmHistory } from "../components/resourceDetail/HelmHistory.tsx";
-import { parse as parseYAML } from "@std/yaml";
 import * as graphlib from "graphlib";
 
 type HelmRelease = {
@@ -146,22 +145,14 @@ export function HelmClassicReleaseDetails() {
       try {
         const ns = hr.metadata.namespace;
         const name = hr.metadata.name;
-        // Fetch history to get latest revision
+        // The server resolves the objects of the latest revision through discovery,
+        // so cluster-scoped objects keep an empty namespace
         const ctxName = apiResourceStore.contextInfo?.current ? encodeURIComponent(apiResourceStore.contextInfo.current) : '';
         const apiPrefix = ctxName ? `/api/${ctxName}` : '/api';
-        const histResp = await fetch(`${apiPrefix}/helm/history/${ns}/${name}`);
-        if (!histResp.ok) throw new Error('Failed to fetch Helm history');
-        const histData = await histResp.json();
-        const releases: Array<{ revision: number }> = Array.isArray(histData.releases) ? histData.releases : [];
-        if (releases.length === 0) return;
-        const latest = releases.sort((a, b) => (b.revision || 0) - (a.revision || 0))[0];
-        const revision = latest.revision;
-        // Fetch manifest for latest revision
-        const manResp = await fetch(`${apiPrefix}/helm/manifest/${ns}/${name}?revision=${revision}`);
-        if (!manResp.ok) throw new Error('Failed to fetch Helm manifest');
-        const manData = await manResp.json();
-        const manifest: string = manData.manifest || '';
-        const resources = parseManifestResources(manifest, ns);
+        const resResp = await fetch(`${apiPrefix}/helm/resources/${ns}/${name}`);
+        if (!resResp.ok) throw new Error('Failed to fetch Helm release resources');
+        const resData = await resResp.json();
+        const resources = toMinimalResources(Array.isArray(resData.resources) ? resData.resources : []);
         setManifestResources(resources);
         // Base resource types from manifest
         const manifestTypes = Array.from(new Set(resources.map(r => r.resourceType)));
@@ -215,27 +206,13 @@ export function HelmClassicReleaseDetails() {
   });
 
   type MinimalRes = { apiVersion: string; kind: string; metadata: { name: string; namespace?: string }; resourceType: string };
-  const parseManifestResources = (yamlContent: string, fallbackNs: string): MinimalRes[] => {
-    if (!yamlContent || yamlContent.trim() === '') return [];
-    const docs = yamlContent.split(/^---$/m).map(d => d.trim()).filter(Boolean);
-    const out: MinimalRes[] = [];
-    for (const doc of docs) {
-      try {
-        const parsed = parseYAML(doc) as unknown;
-        if (!parsed || typeof parsed !== 'object') continue;
-        const obj = parsed as { apiVersion?: string; kind?: string; metadata?: { name?: string; namespace?: string } };
-        const apiVersion = String(obj.apiVersion || 'v1');
-        const kind = String(obj.kind || '');
-        const metadata = obj.metadata || {};
-        const name = String(metadata.name || '');
-        const ns = metadata.namespace || fallbackNs;
-        if (!kind || !name) continue;
-        const group = apiVersion.includes('/') ? apiVersion.split('/')[0] : 'core';
-        const resourceType = `${group}/${kind}`;
-        out.push({ apiVersion, kind, metadata: { name, namespace: ns }, resourceType });
-      } catch (_) {}
-    }
-    return out;
+  const toMinimalResources = (items: Array<{ apiVersion: string; kind: string; name: string; namespace?: string }>): MinimalRes[] => {
+    return items
+      .filter(r => r.kind && r.name)
+      .map(r => {
+        const group = r.apiVersion.includes('/') ? r.apiVersion.split('/')[0] : 'core';
+        return { apiVersion: r.apiVersion, kind: r.kind, metadata: { name: r.name, namespace: r.namespace || '' }, resourceType: `${group}/${r.kind}` };
+      });
   };
 
   const createHelmGraph = (hr: HelmRelease, resList: MinimalRes[]) => {